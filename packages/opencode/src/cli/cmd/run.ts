@@ -5,6 +5,8 @@ import { Provider } from "../../provider/provider"
 import { Session } from "../../session"
 import { Share } from "../../share/share"
 import { Message } from "../../session/message"
+import { Permission } from "../../permission"
+import { PermissionRule } from "../../permission/rule"
 import { UI } from "../ui"
 import { VERSION } from "../version"
 
@@ -42,10 +44,16 @@ export const RunCommand = {
         describe: "Session ID to continue",
         type: "string",
       })
+      .option("yes", {
+        describe:
+          "Allow the permission requests no rule answers, they are denied otherwise",
+        type: "boolean",
+      })
   },
   handler: async (args: {
     message: string[]
     session?: string
+    yes?: boolean
     printLogs?: boolean
   }) => {
     const message = args.message.join(" ")
@@ -114,6 +122,31 @@ export const RunCommand = {
           }
         })
 
+        // there is nobody to ask in a non-interactive run, so anything the
+        // configured rules don't answer is denied unless --yes
+        const rules = await PermissionRule.load()
+        Bus.subscribe(Permission.Event.Updated, async (evt) => {
+          if (evt.properties.sessionID !== session.id) return
+          const rule = PermissionRule.match(rules, evt.properties)
+          const allow = rule ? rule.action === "allow" : args.yes === true
+          if (allow) {
+            printEvent(UI.Style.TEXT_WARNING_BOLD, "Allow", evt.properties.title)
+          } else {
+            printEvent(
+              UI.Style.TEXT_DANGER_BOLD,
+              "Deny",
+              rule
+                ? evt.properties.title
+                : evt.properties.title + ", use --yes to allow it",
+            )
+          }
+          Permission.respond({
+            sessionID: evt.properties.sessionID,
+            permissionID: evt.properties.id,
+            response: allow ? "once" : "reject",
+          })
+        })
+
         const { providerID, modelID } = await Provider.defaultModel()
         await Session.chat({
           sessionID: session.id,
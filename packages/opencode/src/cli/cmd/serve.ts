@@ -0,0 +1,55 @@
+import type { Argv } from "yargs"
+import { App } from "../../app/app"
+import { Server } from "../../server/server"
+import { Share } from "../../share/share"
+import { VERSION } from "../version"
+
+export const ServeCommand = {
+  command: "serve",
+  describe: "Start a headless opencode server",
+  builder: (yargs: Argv) => {
+    return yargs
+      .option("port", {
+        describe: "Port to listen on, 0 picks a free one",
+        type: "number",
+        default: 0,
+      })
+      .option("hostname", {
+        describe: "Hostname to listen on",
+        type: "string",
+        default: "127.0.0.1",
+      })
+      .option("socket", {
+        describe: "Unix socket to listen on instead of a port",
+        type: "string",
+      })
+  },
+  handler: async (args: {
+    port: number
+    hostname: string
+    socket?: string
+  }) => {
+    await App.provide(
+      {
+        cwd: process.cwd(),
+        version: VERSION,
+      },
+      async () => {
+        await Share.init()
+        const server = Server.listen({
+          port: args.port,
+          hostname: args.hostname,
+          socket: args.socket,
+        })
+        const address = args.socket ? "unix://" + args.socket : server.url
+        console.log(`opencode server listening on ${address}`)
+
+        await new Promise<void>((resolve) => {
+          process.on("SIGINT", resolve)
+          process.on("SIGTERM", resolve)
+        })
+        await server.stop()
+      },
+    )
+  },
+}
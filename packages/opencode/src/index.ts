@@ -8,6 +8,7 @@ import { Global } from "./global"
 import yargs from "yargs"
 import { hideBin } from "yargs/helpers"
 import { RunCommand } from "./cli/cmd/run"
+import { ServeCommand } from "./cli/cmd/serve"
 import { GenerateCommand } from "./cli/cmd/generate"
 import { VERSION } from "./cli/version"
 import { ScrapCommand } from "./cli/cmd/scrap"
@@ -36,13 +37,43 @@ const cli = yargs(hideBin(process.argv))
     command: "$0 [project]",
     describe: "Start opencode TUI",
     builder: (yargs) =>
-      yargs.positional("project", {
-        type: "string",
-        describe: "path to start opencode in",
-      }),
+      yargs
+        .positional("project", {
+          type: "string",
+          describe: "path to start opencode in",
+        })
+        .option("cwd", {
+          type: "string",
+          describe: "path to start opencode in",
+        })
+        .option("session", {
+          type: "string",
+          describe: "session ID to open",
+        })
+        .option("continue", {
+          type: "boolean",
+          describe: "open the most recent session",
+        })
+        .option("model", {
+          type: "string",
+          describe: "model to use for this run, as provider/model",
+        })
+        .option("theme", {
+          type: "string",
+          describe: "theme to use for this run",
+        })
+        .option("prompt", {
+          type: "string",
+          describe: "text to fill the editor with",
+        })
+        .option("send", {
+          type: "boolean",
+          describe: "send --prompt right away",
+        }),
     handler: async (args) => {
       while (true) {
-        const cwd = args.project ? path.resolve(args.project) : process.cwd()
+        const project = args.cwd ?? args.project
+        const cwd = project ? path.resolve(project) : process.cwd()
         process.chdir(cwd)
         const result = await App.provide(
           { cwd, version: VERSION },
@@ -78,6 +109,9 @@ const cli = yargs(hideBin(process.argv))
               env: {
                 ...process.env,
                 OPENCODE_SERVER: server.url.toString(),
+                // the arguments are passed through as they are, a relative
+                // project path would be resolved again from the new cwd
+                OPENCODE_CWD: process.cwd(),
               },
               onExit: () => {
                 server.stop()
@@ -100,6 +134,7 @@ const cli = yargs(hideBin(process.argv))
     },
   })
   .command(RunCommand)
+  .command(ServeCommand)
   .command(GenerateCommand)
   .command(ScrapCommand)
   .command(AuthCommand)
@@ -27,16 +27,43 @@ export namespace LSPClient {
     }),
   )
 
+  export const DiagnosticItem = z
+    .object({
+      severity: z.enum(["error", "warning", "info", "hint"]),
+      line: z.number(),
+      character: z.number(),
+      message: z.string(),
+      source: z.string().optional(),
+    })
+    .openapi({
+      ref: "lsp.diagnostic",
+    })
+  export type DiagnosticItem = z.infer<typeof DiagnosticItem>
+
   export const Event = {
     Diagnostics: Bus.event(
       "lsp.client.diagnostics",
       z.object({
         serverID: z.string(),
         path: z.string(),
+        diagnostics: DiagnosticItem.array(),
       }),
     ),
   }
 
+  const SEVERITY = ["error", "warning", "info", "hint"] as const
+
+  // lines and characters are 1-based, like the pretty printed diagnostics
+  function item(diagnostic: Diagnostic): DiagnosticItem {
+    return {
+      severity: SEVERITY[(diagnostic.severity ?? 1) - 1] ?? "error",
+      line: diagnostic.range.start.line + 1,
+      character: diagnostic.range.start.character + 1,
+      message: diagnostic.message,
+      source: diagnostic.source,
+    }
+  }
+
   export async function create(serverID: string, server: LSPServer.Handle) {
     const app = App.info()
     log.info("starting client", { id: serverID })
@@ -53,7 +80,11 @@ export namespace LSPClient {
         path,
       })
       diagnostics.set(path, params.diagnostics)
-      Bus.publish(Event.Diagnostics, { path, serverID })
+      Bus.publish(Event.Diagnostics, {
+        path,
+        serverID,
+        diagnostics: params.diagnostics.map(item),
+      })
     })
     connection.onRequest("workspace/configuration", async () => {
       return [{}]
@@ -10,6 +10,7 @@ export namespace Permission {
     .object({
       id: z.string(),
       sessionID: z.string(),
+      tool: z.string(),
       title: z.string(),
       metadata: z.record(z.any()),
       time: z.object({
@@ -32,15 +33,18 @@ export namespace Permission {
         [sessionID: string]: {
           [permissionID: string]: {
             info: Info
+            pattern?: string
             resolve: () => void
             reject: (e: any) => void
           }
         }
       } = {}
 
+      // approvals for the rest of a session, keyed by tool and the command
+      // or file they were given for
       const approved: {
         [sessionID: string]: {
-          [permissionID: string]: Info
+          [key: string]: Info
         }
       } = {}
 
@@ -61,6 +65,10 @@ export namespace Permission {
   export function ask(input: {
     id: Info["id"]
     sessionID: Info["sessionID"]
+    tool: Info["tool"]
+    // pattern is what an "always" answer approves, like the command or the
+    // file path, the whole tool if it is not set
+    pattern?: string
     title: Info["title"]
     metadata: Info["metadata"]
   }) {
@@ -68,17 +76,21 @@ export namespace Permission {
     log.info("asking", {
       sessionID: input.sessionID,
       permissionID: input.id,
+      tool: input.tool,
     })
-    if (approved[input.sessionID]?.[input.id]) {
+    if (approved[input.sessionID]?.[key(input.tool, input.pattern)]) {
       log.info("previously approved", {
         sessionID: input.sessionID,
         permissionID: input.id,
+        tool: input.tool,
+        pattern: input.pattern,
       })
       return
     }
     const info: Info = {
       id: input.id,
       sessionID: input.sessionID,
+      tool: input.tool,
       title: input.title,
       metadata: input.metadata,
       time: {
@@ -89,24 +101,21 @@ export namespace Permission {
     return new Promise<void>((resolve, reject) => {
       pending[input.sessionID][input.id] = {
         info,
+        pattern: input.pattern,
         resolve,
         reject,
       }
-      setTimeout(() => {
-        respond({
-          sessionID: input.sessionID,
-          permissionID: input.id,
-          response: "always",
-        })
-      }, 1000)
       Bus.publish(Event.Updated, info)
     })
   }
 
+  export const Response = z.enum(["once", "always", "reject"])
+  export type Response = z.infer<typeof Response>
+
   export function respond(input: {
     sessionID: Info["sessionID"]
     permissionID: Info["id"]
-    response: "once" | "always" | "reject"
+    response: Response
   }) {
     log.info("response", input)
     const { pending, approved } = state()
@@ -120,10 +129,16 @@ export namespace Permission {
     match.resolve()
     if (input.response === "always") {
       approved[input.sessionID] = approved[input.sessionID] || {}
-      approved[input.sessionID][input.permissionID] = match.info
+      approved[input.sessionID][key(match.info.tool, match.pattern)] =
+        match.info
     }
   }
 
+  function key(tool: string, pattern?: string) {
+    if (pattern === undefined) return tool
+    return tool + ":" + pattern
+  }
+
   export class RejectedError extends Error {
     constructor(
       public readonly sessionID: string,
@@ -0,0 +1,64 @@
+import path from "path"
+import { App } from "../app/app"
+import { Global } from "../global"
+import { Log } from "../util/log"
+import type { Permission } from "."
+
+// Rules that answer permission requests without asking. The TUI keeps them as
+// [[permission]] tables in its config file and answers requests itself, they
+// are read here for the runs that have no TUI.
+export namespace PermissionRule {
+  const log = Log.create({ service: "permission.rule" })
+
+  export type Info = {
+    tool: string
+    pattern?: string
+    action: "allow" | "deny"
+  }
+
+  export async function load(): Promise<Info[]> {
+    const file = Bun.file(path.join(Global.Path.config, "config"))
+    if (!(await file.exists())) return []
+    try {
+      const config = Bun.TOML.parse(await file.text()) as {
+        permission?: Info[]
+      }
+      return config.permission ?? []
+    } catch (e) {
+      log.error("failed to read permission rules", { error: e })
+      return []
+    }
+  }
+
+  // match returns the rule that answers a permission request, deny rules take
+  // precedence over allow rules
+  export function match(rules: Info[], info: Permission.Info) {
+    const matching = rules.filter(
+      (rule) => rule.tool === info.tool && matches(rule.pattern, info.metadata),
+    )
+    return (
+      matching.find((rule) => rule.action === "deny") ??
+      matching.find((rule) => rule.action === "allow")
+    )
+  }
+
+  function matches(pattern: string | undefined, metadata: Record<string, any>) {
+    if (!pattern) return true
+    if (typeof metadata.filePath === "string") {
+      const relative = path.relative(App.info().path.root, metadata.filePath)
+      if (!relative.startsWith("..") && glob(pattern, relative)) return true
+      return glob(pattern, metadata.filePath)
+    }
+    if (typeof metadata.command === "string") {
+      if (glob(pattern, metadata.command)) return true
+      // a trailing * also covers arguments with slashes, so rm -rf* matches
+      // rm -rf /tmp
+      return pattern.endsWith("*") && glob(pattern + "/**", metadata.command)
+    }
+    return false
+  }
+
+  function glob(pattern: string, input: string) {
+    return new Bun.Glob(pattern).match(input)
+  }
+}
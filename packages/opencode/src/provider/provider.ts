@@ -301,6 +301,11 @@ export namespace Provider {
     return TOOL_MAPPING[providerID] ?? TOOLS
   }
 
+  // allTools lists every built in tool, whichever providers it is given to
+  export function allTools() {
+    return [...new Set(TOOLS)]
+  }
+
   export const ModelNotFoundError = NamedError.create(
     "ProviderModelNotFoundError",
     z.object({
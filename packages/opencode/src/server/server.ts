@@ -14,6 +14,9 @@ import { mapValues } from "remeda"
 import { NamedError } from "../util/error"
 import { Fzf } from "../external/fzf"
 import { ModelsDev } from "../provider/models"
+import { Permission } from "../permission"
+import { MCP } from "../mcp"
+import { zodSchema } from "ai"
 
 const ERRORS = {
   400: {
@@ -39,6 +42,34 @@ export namespace Server {
 
   export type Routes = ReturnType<typeof app>
 
+  type Recorded = {
+    id: string
+    event: any
+  }
+
+  // recent events are kept with an ID so a client that reconnects with
+  // Last-Event-ID gets the ones it missed. IDs start with the time the
+  // server started, IDs of another run don't match any of them.
+  const events = App.state(
+    "server.events",
+    () => {
+      const run = Date.now().toString(36)
+      let counter = 0
+      const recent: Recorded[] = []
+      const listeners = new Set<(item: Recorded) => void>()
+      const unsub = Bus.subscribeAll((event) => {
+        const item = { id: run + "-" + ++counter, event }
+        recent.push(item)
+        if (recent.length > 1000) recent.shift()
+        for (const listener of listeners) listener(item)
+      })
+      return { recent, listeners, unsub }
+    },
+    async (state) => {
+      state.unsub()
+    },
+  )
+
   function app() {
     const app = new Hono()
 
@@ -101,18 +132,28 @@ export namespace Server {
         }),
         async (c) => {
           log.info("event connected")
+          const lastEventID = c.req.header("Last-Event-ID")
           return streamSSE(c, async (stream) => {
+            const { recent, listeners } = events()
             stream.writeSSE({
               data: JSON.stringify({}),
             })
-            const unsub = Bus.subscribeAll(async (event) => {
-              await stream.writeSSE({
-                data: JSON.stringify(event),
+            const write = (item: Recorded) =>
+              stream.writeSSE({
+                id: item.id,
+                data: JSON.stringify(item.event),
               })
-            })
+            // the missed events are written before any new one, an ID that
+            // is no longer kept gets none and the client resyncs instead
+            const index = lastEventID
+              ? recent.findIndex((item) => item.id === lastEventID)
+              : -1
+            if (index !== -1)
+              for (const item of recent.slice(index + 1)) write(item)
+            listeners.add(write)
             await new Promise<void>((resolve) => {
               stream.onAbort(() => {
-                unsub()
+                listeners.delete(write)
                 resolve()
                 log.info("event disconnected")
               })
@@ -269,6 +310,63 @@ export namespace Server {
           return c.json(session)
         },
       )
+      .post(
+        "/session_unshare",
+        describeRoute({
+          description: "Unshare the session",
+          responses: {
+            200: {
+              description: "Successfully unshared session",
+              content: {
+                "application/json": {
+                  schema: resolver(Session.Info),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "json",
+          z.object({
+            sessionID: z.string(),
+          }),
+        ),
+        async (c) => {
+          const body = c.req.valid("json")
+          await Session.unshare(body.sessionID)
+          const session = await Session.get(body.sessionID)
+          return c.json(session)
+        },
+      )
+      .post(
+        "/permission_respond",
+        describeRoute({
+          description: "Respond to a permission request",
+          responses: {
+            200: {
+              description: "Permission response recorded",
+              content: {
+                "application/json": {
+                  schema: resolver(z.boolean()),
+                },
+              },
+            },
+          },
+        }),
+        zValidator(
+          "json",
+          z.object({
+            sessionID: z.string(),
+            permissionID: z.string(),
+            response: Permission.Response,
+          }),
+        ),
+        async (c) => {
+          const body = c.req.valid("json")
+          Permission.respond(body)
+          return c.json(true)
+        },
+      )
       .post(
         "/session_messages",
         describeRoute({
@@ -433,6 +531,58 @@ export namespace Server {
           })
         },
       )
+      .post(
+        "/tool_list",
+        describeRoute({
+          description: "List the tools with their input schemas",
+          responses: {
+            200: {
+              description: "List of tools",
+              content: {
+                "application/json": {
+                  schema: resolver(
+                    z
+                      .object({
+                        id: z.string(),
+                        description: z.string(),
+                        parameters: z.record(z.any()),
+                      })
+                      .openapi({
+                        ref: "tool.info",
+                      })
+                      .array(),
+                  ),
+                },
+              },
+            },
+          },
+        }),
+        async (c) => {
+          const result: {
+            id: string
+            description: string
+            parameters: Record<string, any>
+          }[] = []
+          // tools are named as the model sees them, opencode_edit for
+          // opencode.edit
+          for (const item of Provider.allTools()) {
+            result.push({
+              id: item.id.replaceAll(".", "_"),
+              description: item.description,
+              parameters: zodSchema(item.parameters as any).jsonSchema,
+            })
+          }
+          // MCP tools come with a JSON schema already
+          for (const [id, item] of Object.entries(await MCP.tools())) {
+            result.push({
+              id,
+              description: item.description ?? "",
+              parameters: (item.parameters as any).jsonSchema ?? {},
+            })
+          }
+          return c.json(result)
+        },
+      )
       .post(
         "/file_search",
         describeRoute({
@@ -480,10 +630,20 @@ export namespace Server {
     return result
   }
 
-  export function listen() {
+  export function listen(opts?: {
+    port?: number
+    hostname?: string
+    socket?: string
+  }) {
+    if (opts?.socket)
+      return Bun.serve({
+        unix: opts.socket,
+        idleTimeout: 0,
+        fetch: app().fetch,
+      })
     const server = Bun.serve({
-      port: 0,
-      hostname: "0.0.0.0",
+      port: opts?.port ?? 0,
+      hostname: opts?.hostname ?? "0.0.0.0",
       idleTimeout: 0,
       fetch: app().fetch,
     })
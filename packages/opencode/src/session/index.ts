@@ -11,6 +11,7 @@ import {
   tool,
   type Tool as AITool,
   type LanguageModelUsage,
+  type ProviderMetadata,
   type CoreMessage,
   type UIMessage,
 } from "ai"
@@ -64,6 +65,7 @@ export namespace Session {
     Error: Bus.event(
       "session.error",
       z.object({
+        sessionID: z.string(),
         error: Message.Info.shape.metadata.shape.error,
       }),
     ),
@@ -129,6 +131,15 @@ export namespace Session {
     return share
   }
 
+  export async function unshare(id: string) {
+    const session = await get(id)
+    if (!session.share) return
+    await Share.remove(id, session.share.secret)
+    await update(id, (draft) => {
+      delete draft.share
+    })
+  }
+
   export async function update(id: string, editor: (session: Info) => void) {
     const { sessions } = state()
     const session = await get(id)
@@ -315,6 +326,7 @@ export namespace Session {
               sessionID: input.sessionID,
               abort: abort.signal,
               messageID: next.id,
+              callID: opts.toolCallId,
             })
             next.metadata!.tool![opts.toolCallId] = {
               ...result.metadata,
@@ -379,13 +391,14 @@ export namespace Session {
     }
 
     let text: Message.TextPart | undefined
+    let reasoning: Message.ReasoningPart | undefined
     const result = streamText({
       onStepFinish: async (step) => {
         log.info("step finish", {
           finishReason: step.finishReason,
         })
         const assistant = next.metadata!.assistant!
-        const usage = getUsage(step.usage, model.info)
+        const usage = getUsage(step.usage, model.info, step.providerMetadata)
         assistant.cost += usage.cost
         assistant.tokens = usage.tokens
         await updateMessage(next)
@@ -397,6 +410,7 @@ export namespace Session {
           })
         }
         text = undefined
+        reasoning = undefined
       },
       async onFinish(input) {
         const assistant = next.metadata!.assistant!
@@ -428,9 +442,12 @@ export namespace Session {
               { cause: err.error },
             )
         }
-        Bus.publish(Event.Error, {
-          error: next.metadata.error,
-        })
+        // an interrupted prompt is not an error to report
+        if (!abort.signal.aborted)
+          Bus.publish(Event.Error, {
+            sessionID: input.sessionID,
+            error: next.metadata.error,
+          })
       },
       // async prepareStep(step) {
       //   next.parts.push({
@@ -471,6 +488,28 @@ export namespace Session {
               type: "step-start",
             })
             break
+          case "reasoning":
+            if (!reasoning) {
+              reasoning = {
+                type: "reasoning",
+                text: value.textDelta,
+              }
+              next.parts.push(reasoning)
+              break
+            } else reasoning.text += value.textDelta
+            break
+
+          case "source":
+            if (value.source.sourceType === "url")
+              next.parts.push({
+                type: "source-url",
+                sourceId: value.source.id,
+                url: value.source.url,
+                title: value.source.title,
+                providerMetadata: value.source.providerMetadata,
+              })
+            break
+
           case "text-delta":
             if (!text) {
               text = {
@@ -582,9 +621,11 @@ export namespace Session {
             { cause: e },
           )
       }
-      Bus.publish(Event.Error, {
-        error: next.metadata.error,
-      })
+      if (!abort.signal.aborted)
+        Bus.publish(Event.Error, {
+          sessionID: input.sessionID,
+          error: next.metadata.error,
+        })
     }
     next.metadata!.time.completed = Date.now()
     for (const part of next.parts) {
@@ -677,6 +718,7 @@ export namespace Session {
     const usage = getUsage(result.usage, model.info)
     assistant.cost = usage.cost
     assistant.tokens = usage.tokens
+    next.metadata!.time.completed = Date.now()
     await updateMessage(next)
   }
 
@@ -695,11 +737,16 @@ export namespace Session {
     }
   }
 
-  function getUsage(usage: LanguageModelUsage, model: ModelsDev.Model) {
+  function getUsage(
+    usage: LanguageModelUsage,
+    model: ModelsDev.Model,
+    metadata?: ProviderMetadata,
+  ) {
     const tokens = {
       input: usage.promptTokens ?? 0,
       output: usage.completionTokens ?? 0,
-      reasoning: 0,
+      // only reported separately by some providers
+      reasoning: Number(metadata?.["openai"]?.["reasoningTokens"] ?? 0),
     }
     return {
       cost: new Decimal(0)
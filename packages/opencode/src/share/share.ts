@@ -65,4 +65,11 @@ export namespace Share {
       .then((x) => x.json())
       .then((x) => x as { url: string; secret: string })
   }
+
+  export async function remove(sessionID: string, secret: string) {
+    return fetch(`${URL}/share_delete`, {
+      method: "POST",
+      body: JSON.stringify({ sessionID, secret }),
+    }).then((x) => x.json())
+  }
 }
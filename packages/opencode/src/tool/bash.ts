@@ -1,5 +1,6 @@
 import { z } from "zod"
 import { Tool } from "./tool"
+import { Permission } from "../permission"
 import DESCRIPTION from "./bash.txt"
 
 const MAX_OUTPUT_LENGTH = 30000
@@ -47,6 +48,18 @@ export const BashTool = Tool.define({
     if (BANNED_COMMANDS.some((item) => params.command.startsWith(item)))
       throw new Error(`Command '${params.command}' is not allowed`)
 
+    await Permission.ask({
+      id: ctx.callID,
+      sessionID: ctx.sessionID,
+      tool: "opencode_bash",
+      pattern: params.command,
+      title: "Run this command: " + params.command,
+      metadata: {
+        command: params.command,
+        description: params.description,
+      },
+    })
+
     const process = Bun.spawn({
       cmd: ["bash", "-c", params.command],
       maxBuffer: MAX_OUTPUT_LENGTH,
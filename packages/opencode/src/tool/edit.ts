@@ -35,13 +35,21 @@ export const EditTool = Tool.define({
       : path.join(app.path.cwd, params.filePath)
 
     await Permission.ask({
-      id: "opencode.edit",
+      id: ctx.callID,
       sessionID: ctx.sessionID,
+      tool: "opencode_edit",
+      pattern: filepath,
       title: "Edit this file: " + filepath,
       metadata: {
         filePath: filepath,
         oldString: params.oldString,
         newString: params.newString,
+        diff: createTwoFilesPatch(
+          filepath,
+          filepath,
+          params.oldString,
+          params.newString,
+        ),
       },
     })
 
@@ -8,6 +8,8 @@ export namespace Tool {
   export type Context = {
     sessionID: string
     messageID: string
+    // callID is the ID of the tool call being executed
+    callID: string
     abort: AbortSignal
   }
   export interface Info<
@@ -3,6 +3,7 @@ import * as path from "path"
 import { Tool } from "./tool"
 import { FileTimes } from "./util/file-times"
 import { LSP } from "../lsp"
+import { createTwoFilesPatch } from "diff"
 import { Permission } from "../permission"
 import DESCRIPTION from "./write.txt"
 import { App } from "../app/app"
@@ -29,8 +30,10 @@ export const WriteTool = Tool.define({
     if (exists) await FileTimes.assert(ctx.sessionID, filepath)
 
     await Permission.ask({
-      id: "opencode.write",
+      id: ctx.callID,
       sessionID: ctx.sessionID,
+      tool: "opencode_write",
+      pattern: filepath,
       title: exists
         ? "Overwrite this file: " + filepath
         : "Create new file: " + filepath,
@@ -38,6 +41,12 @@ export const WriteTool = Tool.define({
         filePath: filepath,
         content: params.content,
         exists,
+        diff: createTwoFilesPatch(
+          filepath,
+          filepath,
+          exists ? await file.text() : "",
+          params.content,
+        ),
       },
     })
 
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/sst/opencode/internal/app"
+)
+
+type tuiFlags struct {
+	options app.Options
+	cwd     string
+}
+
+// parseFlags parses the interactive command line. The launcher passes its own
+// arguments through, so a positional project directory is accepted as an
+// alias for --cwd.
+func parseFlags(args []string) (tuiFlags, error) {
+	result := tuiFlags{}
+
+	flags := flag.NewFlagSet("opencode", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: opencode [flags] [project]")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&result.options.Session, "session", "", "session ID to open")
+	flags.BoolVar(&result.options.Continue, "continue", false, "open the most recent session")
+	flags.StringVar(&result.options.Model, "model", "", "model to use for this run, as provider/model")
+	flags.StringVar(&result.options.Theme, "theme", "", "theme to use for this run")
+	flags.StringVar(&result.options.Prompt, "prompt", "", "text to fill the editor with")
+	flags.BoolVar(&result.options.Send, "send", false, "send --prompt right away")
+	flags.StringVar(&result.cwd, "cwd", "", "directory to start in, it must match the directory of the server when OPENCODE_SERVER is set")
+	// handled by the launcher
+	flags.Bool("print-logs", false, "print logs to stderr")
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return result, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		if result.cwd != "" {
+			return result, fmt.Errorf("unexpected argument %q", args[0])
+		}
+		result.cwd = args[0]
+		args = args[1:]
+	}
+
+	if result.options.Session != "" && result.options.Continue {
+		return result, fmt.Errorf("--session and --continue cannot be used together")
+	}
+	if result.options.Send && result.options.Prompt == "" {
+		return result, fmt.Errorf("--send requires --prompt")
+	}
+	return result, nil
+}
+
+// sameDir reports whether two paths name the same directory
+func sameDir(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
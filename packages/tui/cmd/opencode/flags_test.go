@@ -0,0 +1,113 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sst/opencode/internal/app"
+)
+
+func TestParseFlags(t *testing.T) {
+	// the flag package prints the usage of a failed parse
+	stderr := os.Stderr
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = devNull
+	t.Cleanup(func() {
+		os.Stderr = stderr
+		devNull.Close()
+	})
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    tuiFlags
+		wantErr bool
+	}{
+		{
+			name: "no arguments",
+			args: []string{},
+			want: tuiFlags{},
+		},
+		{
+			name: "options",
+			args: []string{"--session", "ses_1", "--model", "anthropic/claude-sonnet-4", "--theme", "tokyonight"},
+			want: tuiFlags{options: app.Options{Session: "ses_1", Model: "anthropic/claude-sonnet-4", Theme: "tokyonight"}},
+		},
+		{
+			name: "prompt and send",
+			args: []string{"-prompt", "fix the tests", "-send"},
+			want: tuiFlags{options: app.Options{Prompt: "fix the tests", Send: true}},
+		},
+		{
+			name: "positional project",
+			args: []string{"../project"},
+			want: tuiFlags{cwd: "../project"},
+		},
+		{
+			name: "flags after the project",
+			args: []string{"../project", "--continue"},
+			want: tuiFlags{cwd: "../project", options: app.Options{Continue: true}},
+		},
+		{
+			name: "flags before the project",
+			args: []string{"--continue", "../project"},
+			want: tuiFlags{cwd: "../project", options: app.Options{Continue: true}},
+		},
+		{
+			name: "cwd flag",
+			args: []string{"--cwd", "/tmp/project"},
+			want: tuiFlags{cwd: "/tmp/project"},
+		},
+		{
+			name: "print-logs is accepted",
+			args: []string{"--print-logs"},
+			want: tuiFlags{},
+		},
+		{name: "cwd and project", args: []string{"--cwd", "/tmp/a", "/tmp/b"}, wantErr: true},
+		{name: "two projects", args: []string{"/tmp/a", "/tmp/b"}, wantErr: true},
+		{name: "session and continue", args: []string{"--session", "ses_1", "--continue"}, wantErr: true},
+		{name: "send without prompt", args: []string{"--send"}, wantErr: true},
+		{name: "unknown flag", args: []string{"--verbose"}, wantErr: true},
+		{name: "missing value", args: []string{"--model"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFlags(%q) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseFlags(%q) = %+v, want %+v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSameDir(t *testing.T) {
+	dir := t.TempDir()
+	other := t.TempDir()
+	tests := []struct {
+		name string
+		a, b string
+		want bool
+	}{
+		{name: "same path", a: dir, b: dir, want: true},
+		{name: "trailing slash", a: dir, b: dir + "/", want: true},
+		{name: "through a parent", a: dir, b: dir + "/../" + filepath.Base(dir), want: true},
+		{name: "different directories", a: dir, b: other, want: false},
+		{name: "missing directory", a: dir, b: dir + "/missing", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameDir(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameDir(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
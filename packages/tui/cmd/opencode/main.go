@@ -2,6 +2,10 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
@@ -12,7 +16,10 @@ import (
 	tea "github.com/charmbracelet/bubbletea/v2"
 	zone "github.com/lrstanley/bubblezone"
 	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/config"
+	"github.com/sst/opencode/internal/logging"
 	"github.com/sst/opencode/internal/pubsub"
+	"github.com/sst/opencode/internal/server"
 	"github.com/sst/opencode/internal/tui"
 	"github.com/sst/opencode/pkg/client"
 )
@@ -20,15 +27,81 @@ import (
 var Version = "dev"
 
 func main() {
-	url := os.Getenv("OPENCODE_SERVER")
-	httpClient, err := client.NewClientWithResponses(url)
+	interactive := len(os.Args) < 2 || os.Args[1] != "run"
+	tuiFlags := tuiFlags{}
+	if interactive {
+		var err error
+		tuiFlags, err = parseFlags(os.Args[1:])
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(2)
+		}
+		// the launcher has already resolved the project directory and moved
+		// into it, a relative one in the arguments would be resolved twice
+		if cwd := os.Getenv("OPENCODE_CWD"); cwd != "" {
+			tuiFlags.cwd = cwd
+		}
+	}
+
+	transport := client.TransportConfigFromEnv()
+	var supervisor *server.Supervisor
+	if transport.Server == "" {
+		// the server works in its own directory, so --cwd only matters when
+		// we start one
+		if tuiFlags.cwd != "" {
+			if err := os.Chdir(tuiFlags.cwd); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				os.Exit(1)
+			}
+		}
+		var err error
+		supervisor, err = server.Start(context.Background())
+		if err != nil {
+			slog.Error("Failed to start server", "error", err)
+			os.Exit(1)
+		}
+		defer supervisor.Stop()
+		transport.Server = supervisor.URL()
+	}
+	url := transport.ServerURL()
+	clientOpts, err := transport.Options()
+	if err != nil {
+		slog.Error("Failed to configure client", "error", err)
+		supervisor.Stop()
+		os.Exit(1)
+	}
+
+	httpClient, err := client.NewClientWithResponses(url, clientOpts...)
 	if err != nil {
 		slog.Error("Failed to create client", "error", err)
+		supervisor.Stop()
+		os.Exit(1)
+	}
+	eventClient, err := client.NewClient(url, clientOpts...)
+	if err != nil {
+		slog.Error("Failed to create event client", "error", err)
+		supervisor.Stop()
 		os.Exit(1)
 	}
 	paths, err := httpClient.PostPathGetWithResponse(context.Background())
 	if err != nil {
-		panic(err)
+		fmt.Fprintf(os.Stderr, "error: failed to reach the opencode server at %s: %v\n", url, err)
+		supervisor.Stop()
+		os.Exit(1)
+	}
+	if paths.JSON200 == nil {
+		fmt.Fprintf(os.Stderr, "error: the opencode server at %s answered with status %d\n", url, paths.StatusCode())
+		supervisor.Stop()
+		os.Exit(1)
+	}
+	if supervisor == nil && tuiFlags.cwd != "" && !sameDir(tuiFlags.cwd, paths.JSON200.Cwd) {
+		// a server we didn't start can't be moved, the launcher handles
+		// --cwd itself before starting one
+		fmt.Fprintf(os.Stderr, "error: --cwd %s does not match the directory of the server at %s (%s)\n", tuiFlags.cwd, url, paths.JSON200.Cwd)
+		os.Exit(2)
 	}
 	logfile := filepath.Join(paths.JSON200.Data, "log", "tui.log")
 
@@ -36,18 +109,30 @@ func main() {
 		err := os.MkdirAll(filepath.Dir(logfile), 0755)
 		if err != nil {
 			slog.Error("Failed to create log directory", "error", err)
+			supervisor.Stop()
 			os.Exit(1)
 		}
 	}
-	file, err := os.Create(logfile)
+	file, err := logging.OpenRotatingFile(logfile, logging.DefaultRotation)
 	if err != nil {
 		slog.Error("Failed to create log file", "error", err)
+		supervisor.Stop()
 		os.Exit(1)
 	}
 	defer file.Close()
-	logger := slog.New(slog.NewTextHandler(file, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	level, format := logSettings(filepath.Join(paths.JSON200.Config, "config"))
+	logging.Level.Set(level)
+	logger := slog.New(logging.NewHandler(io.MultiWriter(file, logging.Recent), format))
 	slog.SetDefault(logger)
 
+	if supervisor != nil {
+		serverLog := filepath.Join(filepath.Dir(logfile), "server.log")
+		if err := supervisor.SetLogFile(serverLog); err != nil {
+			slog.Error("Failed to create server log file", "error", err)
+		}
+		slog.Info("Started opencode server", "url", transport.Server, "log", serverLog)
+	}
+
 	// Create main context for the application
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -56,9 +141,22 @@ func main() {
 	if version != "dev" && !strings.HasPrefix(Version, "v") {
 		version = "v" + Version
 	}
-	app_, err := app.New(ctx, version, httpClient)
+	logging.Init(paths.JSON200.Data, version)
+
+	if !interactive {
+		code := runNonInteractive(ctx, version, httpClient, eventClient, os.Args[2:])
+		cancel()
+		supervisor.Stop()
+		file.Close()
+		os.Exit(code)
+	}
+	restoreDraft(&tuiFlags.options)
+	app_, err := app.New(ctx, version, httpClient, tuiFlags.options)
 	if err != nil {
-		panic(err)
+		slog.Error("Failed to start app", "error", err)
+		fmt.Fprintln(os.Stderr, "error:", err)
+		supervisor.Stop()
+		os.Exit(1)
 	}
 
 	// Set up the TUI
@@ -70,24 +168,22 @@ func main() {
 		tea.WithAltScreen(),
 	)
 
-	eventClient, err := client.NewClient(url)
-	if err != nil {
-		slog.Error("Failed to create event client", "error", err)
-		os.Exit(1)
-	}
-
-	evts, err := eventClient.Event(ctx)
-	if err != nil {
+	dispatcher := client.NewEventDispatcher(eventClient)
+	dispatcher.HandleAll(func(event any) {
+		defer logging.RecoverPanic("event-handler", func() {
+			attemptTUIRecovery(program)
+		})
+		program.Send(event)
+	})
+	dispatcher.HandleError(func(err error) {
+		slog.Warn("Failed to decode event", "error", err)
+	})
+	if err := dispatcher.Start(ctx); err != nil {
 		slog.Error("Failed to subscribe to events", "error", err)
+		supervisor.Stop()
 		os.Exit(1)
 	}
 
-	go func() {
-		for item := range evts {
-			program.Send(item)
-		}
-	}()
-
 	// Setup the subscriptions, this will send services events to the TUI
 	ch, cancelSubs := setupSubscriptions(app_, ctx)
 
@@ -99,9 +195,9 @@ func main() {
 	// Set up message handling for the TUI
 	go func() {
 		defer tuiWg.Done()
-		// defer logging.RecoverPanic("TUI-message-handler", func() {
-		// 	attemptTUIRecovery(program)
-		// })
+		defer logging.RecoverPanic("TUI-message-handler", func() {
+			attemptTUIRecovery(program)
+		})
 
 		for {
 			select {
@@ -138,10 +234,72 @@ func main() {
 
 	if err != nil {
 		slog.Error("TUI error", "error", err)
-		// return fmt.Errorf("TUI error: %v", err)
+		// bubbletea recovered this one itself and already printed the stack
+		if errors.Is(err, tea.ErrProgramPanic) && logging.LastCrashReport() == "" {
+			logging.ReportPanic("program", err, nil)
+		}
 	}
 
 	slog.Info("TUI exited", "result", result)
+	if report := logging.LastCrashReport(); report != "" {
+		fmt.Fprintf(os.Stderr, "opencode crashed, a report was saved to %s\n", report)
+		supervisor.Stop()
+		file.Close()
+		os.Exit(1)
+	}
+}
+
+// logSettings returns the log level and format from the environment, falling
+// back to the TUI config file and then to debug level text logs.
+func logSettings(configPath string) (slog.Level, string) {
+	levelName := os.Getenv("OPENCODE_LOG_LEVEL")
+	format := os.Getenv("OPENCODE_LOG_FORMAT")
+	if cfg, err := config.LoadConfig(configPath); err == nil {
+		if levelName == "" {
+			levelName = cfg.LogLevel
+		}
+		if format == "" {
+			format = cfg.LogFormat
+		}
+	}
+
+	level := slog.LevelDebug
+	if levelName != "" {
+		parsed, err := logging.ParseLevel(levelName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: unknown log level %q, using debug\n", levelName)
+		} else {
+			level = parsed
+		}
+	}
+	return level, format
+}
+
+// restoreDraft fills in the prompt and session from a draft saved by a
+// previous crash, unless they were given on the command line. The session
+// is only reopened if it still exists, the text is restored either way.
+func restoreDraft(options *app.Options) {
+	if options.Prompt != "" || options.Session != "" || options.Continue {
+		return
+	}
+	draft, err := logging.LoadDraft()
+	if err != nil {
+		slog.Error("Failed to load draft", "error", err)
+		return
+	}
+	if draft == nil {
+		return
+	}
+	slog.Info("Restoring draft", "session", draft.SessionID)
+	options.Prompt = draft.Text
+	options.DraftSession = draft.SessionID
+}
+
+func attemptTUIRecovery(program *tea.Program) {
+	slog.Info("Attempting to recover TUI after panic")
+	// quitting restores the terminal; the draft and crash report have
+	// already been saved
+	program.Quit()
 }
 
 func setupSubscriber[T any](
@@ -154,7 +312,7 @@ func setupSubscriber[T any](
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		// defer logging.RecoverPanic(fmt.Sprintf("subscription-%s", name), nil)
+		defer logging.RecoverPanic(fmt.Sprintf("subscription-%s", name), nil)
 
 		subCh := subscriber(ctx)
 		if subCh == nil {
@@ -202,7 +360,7 @@ func setupSubscriptions(app *app.App, parentCtx context.Context) (chan tea.Msg,
 
 		waitCh := make(chan struct{})
 		go func() {
-			// defer logging.RecoverPanic("subscription-cleanup", nil)
+			defer logging.RecoverPanic("subscription-cleanup", nil)
 			wg.Wait()
 			close(waitCh)
 		}()
@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/image"
+	"github.com/sst/opencode/pkg/client"
+)
+
+var toolNames = map[string]string{
+	"opencode_todowrite": "Todo",
+	"opencode_todoread":  "Todo",
+	"opencode_bash":      "Bash",
+	"opencode_edit":      "Edit",
+	"opencode_glob":      "Glob",
+	"opencode_grep":      "Grep",
+	"opencode_ls":        "List",
+	"opencode_read":      "Read",
+	"opencode_write":     "Write",
+	"opencode_webfetch":  "Fetch",
+}
+
+const maxStdinSize = 5 * 1024 * 1024 // 5MB
+
+type chatResult struct {
+	message *client.MessageInfo
+	err     error
+}
+
+// runNonInteractive sends a single prompt to the server and streams the
+// assistant's response to stdout. It returns the process exit code.
+func runNonInteractive(ctx context.Context, version string, httpClient *client.ClientWithResponses, eventClient *client.Client, args []string) int {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: [command |] opencode run [--session id] [--model provider/model] [--format text|json] [--yes] <prompt> [-]")
+		fmt.Fprintln(flags.Output(), "stdin is read when it is a pipe or a file, or when - is given")
+		flags.PrintDefaults()
+	}
+	sessionID := flags.String("session", "", "session ID to continue")
+	model := flags.String("model", "", "model to use, as provider/model")
+	format := flags.String("format", "text", "output format: text or json")
+	yes := flags.Bool("yes", false, "allow the permission requests no rule answers, they are denied otherwise")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unknown format %q\n", *format)
+		return 2
+	}
+
+	// a lone - asks for stdin whatever it is connected to
+	words := []string{}
+	forceStdin := false
+	for _, arg := range flags.Args() {
+		if arg == "-" {
+			forceStdin = true
+			continue
+		}
+		words = append(words, arg)
+	}
+	prompt := strings.TrimSpace(strings.Join(words, " "))
+	stdin, err := readStdin(maxStdinSize, forceStdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if prompt == "" && len(stdin) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	app_, err := app.New(ctx, version, httpClient, app.Options{Model: *model})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if app_.Provider == nil || app_.Model == nil {
+		fmt.Fprintln(os.Stderr, "error: no provider or model configured")
+		return 1
+	}
+
+	if *sessionID != "" {
+		app_.Session = &client.SessionInfo{Id: *sessionID}
+	} else {
+		session, err := app_.CreateSession(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		app_.Session = session
+	}
+	slog.Info("Running prompt", "session", app_.Session.Id)
+
+	// subscribe before sending so no part of the response is missed
+	stream, err := eventClient.Event(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	go func() {
+		for err := range stream.Errors {
+			slog.Warn("Failed to decode event", "error", err)
+		}
+	}()
+	events := stream.Events
+
+	parts, err := promptParts(prompt, stdin, app_.Model)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	done := make(chan chatResult, 1)
+	go func() {
+		response, err := app_.Client.PostSessionChatWithResponse(ctx, client.PostSessionChatJSONRequestBody{
+			SessionID:  app_.Session.Id,
+			Parts:      parts,
+			ProviderID: app_.Provider.Id,
+			ModelID:    app_.Model.Id,
+		})
+		if err != nil {
+			done <- chatResult{err: err}
+			return
+		}
+		if response.StatusCode() != 200 || response.JSON200 == nil {
+			done <- chatResult{err: fmt.Errorf("failed to send message: %d", response.StatusCode())}
+			return
+		}
+		done <- chatResult{message: response.JSON200}
+	}()
+
+	var out runOutput
+	switch *format {
+	case "json":
+		out = newJSONOutput(os.Stdout, app_.Session.Id)
+	default:
+		out = newTextOutput(os.Stdout, os.Stderr, app_.Session.Id)
+	}
+
+	messages := make(map[string]client.MessageInfo)
+	runError := ""
+	finish := func(result chatResult) int {
+		summary := runSummary{
+			Type:      "summary",
+			SessionID: app_.Session.Id,
+		}
+		if result.err != nil {
+			summary.Error = result.err.Error()
+		} else {
+			// the response carries the finished message, so anything the
+			// event stream did not deliver is emitted from it
+			message := *result.message
+			messages[message.Id] = message
+			out.flush(message)
+			summary.Error = runError
+			if message.Metadata.Error != nil && summary.Error == "" {
+				summary.Error = errorMessage(message.Metadata.Error)
+			}
+		}
+
+		for _, message := range messages {
+			if message.Metadata.Assistant == nil {
+				continue
+			}
+			summary.Cost += message.Metadata.Assistant.Cost
+			summary.Tokens.Input += message.Metadata.Assistant.Tokens.Input
+			summary.Tokens.Output += message.Metadata.Assistant.Tokens.Output
+			summary.Tokens.Reasoning += message.Metadata.Assistant.Tokens.Reasoning
+		}
+		if summary.Error != "" {
+			summary.ExitCode = 1
+		}
+		out.done(summary)
+		return summary.ExitCode
+	}
+
+	// the chat response can arrive before the last events, so once it is in
+	// the stream is drained until the message completes or the grace period ends
+	var pending *chatResult
+	var grace <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return 1
+		case <-grace:
+			return finish(*pending)
+		case evt, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			switch evt := evt.(type) {
+			case client.EventMessageUpdated:
+				if evt.Properties.Info.Metadata.SessionID == app_.Session.Id {
+					messages[evt.Properties.Info.Id] = evt.Properties.Info
+				}
+			case client.EventPermissionUpdated:
+				// there is nobody to ask in a non-interactive run, so anything
+				// the configured rules don't answer is denied unless --yes
+				if evt.Properties.SessionID == app_.Session.Id && !app_.AnswerByRule(ctx, evt.Properties) {
+					if *yes {
+						app_.RespondPermission(ctx, evt.Properties, client.Once)
+					} else {
+						fmt.Fprintf(os.Stderr, "denied: %s, use --yes to allow it\n", evt.Properties.Title)
+						app_.RespondPermission(ctx, evt.Properties, client.Reject)
+					}
+				}
+			case client.EventSessionError:
+				if evt.Properties.SessionID == app_.Session.Id && evt.Properties.Error != nil && runError == "" {
+					runError = errorMessage(evt.Properties.Error)
+				}
+			}
+			out.event(evt)
+			if pending != nil && completed(messages, pending.message.Id) {
+				return finish(*pending)
+			}
+		case result := <-done:
+			if result.err != nil || completed(messages, result.message.Id) {
+				return finish(result)
+			}
+			pending = &result
+			grace = time.After(time.Second)
+		}
+	}
+}
+
+// readStdin returns the piped input. Stdin is only read when it is a pipe or
+// a regular file, or when force is set: a terminal or a socket left open by
+// a CI runner or ssh would block forever.
+func readStdin(sizeLimit int64, force bool) ([]byte, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if !force && info.Mode()&os.ModeNamedPipe == 0 && !info.Mode().IsRegular() {
+		return nil, nil
+	}
+
+	data, tooLarge, err := image.ValidateReaderSize(os.Stdin, sizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	if tooLarge {
+		return nil, fmt.Errorf("stdin exceeds the maximum size of %d bytes", sizeLimit)
+	}
+	return data, nil
+}
+
+// promptParts builds the message parts for the prompt. Piped input is sent
+// as a file part, or inlined as a fenced block when the model does not
+// accept attachments.
+func promptParts(prompt string, stdin []byte, model *client.ModelInfo) ([]client.MessagePart, error) {
+	if len(stdin) == 0 {
+		return app.MessageParts(prompt, nil), nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(stdin))
+	if !model.Attachment {
+		if !utf8.Valid(stdin) {
+			return nil, fmt.Errorf("%s does not support attachments and stdin is not text (%s)", model.Name, mediaType)
+		}
+		prompt = strings.TrimSpace(prompt + "\n\n```\n" + strings.TrimRight(string(stdin), "\n") + "\n```")
+		return app.MessageParts(prompt, nil), nil
+	}
+
+	return app.MessageParts(prompt, []app.Attachment{{
+		FileName: "stdin",
+		MimeType: mediaType,
+		Content:  stdin,
+	}}), nil
+}
+
+func completed(messages map[string]client.MessageInfo, id string) bool {
+	message, ok := messages[id]
+	return ok && message.Metadata.Time.Completed != nil
+}
+
+type runSummary struct {
+	Type      string `json:"type"`
+	SessionID string `json:"sessionID"`
+	Tokens    struct {
+		Input     float32 `json:"input"`
+		Output    float32 `json:"output"`
+		Reasoning float32 `json:"reasoning"`
+	} `json:"tokens"`
+	Cost     float32 `json:"cost"`
+	Error    string  `json:"error,omitempty"`
+	ExitCode int     `json:"exitCode"`
+}
+
+// runOutput renders the server events of a non-interactive run.
+type runOutput interface {
+	event(evt any)
+	flush(message client.MessageInfo)
+	done(summary runSummary)
+}
+
+// textOutput writes assistant text and tool summaries for a single session,
+// making sure each part is only printed once.
+type textOutput struct {
+	out       io.Writer
+	errOut    io.Writer
+	sessionID string
+	messages  map[string]client.MessageInfo
+	printed   map[string]bool
+}
+
+func newTextOutput(out io.Writer, errOut io.Writer, sessionID string) *textOutput {
+	return &textOutput{
+		out:       out,
+		errOut:    errOut,
+		sessionID: sessionID,
+		messages:  make(map[string]client.MessageInfo),
+		printed:   make(map[string]bool),
+	}
+}
+
+func (o *textOutput) event(evt any) {
+	switch evt := evt.(type) {
+	case client.EventMessageUpdated:
+		if evt.Properties.Info.Metadata.SessionID == o.sessionID {
+			o.messages[evt.Properties.Info.Id] = evt.Properties.Info
+		}
+	case client.EventMessagePartUpdated:
+		if evt.Properties.SessionID == o.sessionID {
+			o.partUpdated(evt.Properties.MessageID, evt.Properties.Part)
+		}
+	}
+}
+
+func (o *textOutput) flush(message client.MessageInfo) {
+	o.messages[message.Id] = message
+	for _, part := range message.Parts {
+		o.partUpdated(message.Id, part)
+	}
+}
+
+func (o *textOutput) done(summary runSummary) {
+	if summary.Error != "" {
+		fmt.Fprintln(o.errOut, "error:", summary.Error)
+	}
+}
+
+func (o *textOutput) partUpdated(messageID string, part client.MessagePart) {
+	if message, ok := o.messages[messageID]; ok && message.Role != client.Assistant {
+		return
+	}
+
+	value, err := part.ValueByDiscriminator()
+	if err != nil {
+		return
+	}
+
+	switch value := value.(type) {
+	case client.MessagePartText:
+		key := messageID + ":" + value.Text
+		if o.printed[key] || strings.TrimSpace(value.Text) == "" {
+			return
+		}
+		o.printed[key] = true
+		fmt.Fprintln(o.out)
+		fmt.Fprintln(o.out, strings.TrimSpace(value.Text))
+		fmt.Fprintln(o.out)
+	case client.MessagePartToolInvocation:
+		result, err := value.ToolInvocation.AsMessageToolInvocationToolResult()
+		if err != nil || result.State != "result" || o.printed[result.ToolCallId] {
+			return
+		}
+		o.printed[result.ToolCallId] = true
+
+		name, ok := toolNames[result.ToolName]
+		if !ok {
+			name = result.ToolName
+		}
+		title := ""
+		if metadata, ok := o.messages[messageID].Metadata.Tool[result.ToolCallId]; ok {
+			title = metadata.Title
+		}
+		fmt.Fprintf(o.out, "| %-7s %s\n", name, title)
+	}
+}
+
+// jsonOutput writes every relevant server event as a single JSON line,
+// followed by a summary record once the run is finished.
+type jsonOutput struct {
+	encoder   *json.Encoder
+	sessionID string
+	completed map[string]bool
+}
+
+func newJSONOutput(out io.Writer, sessionID string) *jsonOutput {
+	return &jsonOutput{
+		encoder:   json.NewEncoder(out),
+		sessionID: sessionID,
+		completed: make(map[string]bool),
+	}
+}
+
+func (o *jsonOutput) event(evt any) {
+	switch e := evt.(type) {
+	case client.EventMessageUpdated:
+		if e.Properties.Info.Metadata.SessionID != o.sessionID {
+			return
+		}
+		if e.Properties.Info.Metadata.Time.Completed != nil {
+			o.completed[e.Properties.Info.Id] = true
+		}
+	case client.EventMessagePartUpdated:
+		if e.Properties.SessionID != o.sessionID {
+			return
+		}
+	case client.EventPermissionUpdated:
+		if e.Properties.SessionID != o.sessionID {
+			return
+		}
+	case client.EventSessionError:
+		if e.Properties.SessionID != o.sessionID {
+			return
+		}
+	default:
+		return
+	}
+	o.write(evt)
+}
+
+func (o *jsonOutput) flush(message client.MessageInfo) {
+	if o.completed[message.Id] {
+		return
+	}
+	evt := client.EventMessageUpdated{Type: "message.updated"}
+	evt.Properties.Info = message
+	o.event(evt)
+}
+
+func (o *jsonOutput) done(summary runSummary) {
+	o.write(summary)
+}
+
+func (o *jsonOutput) write(value any) {
+	if err := o.encoder.Encode(value); err != nil {
+		slog.Error("Failed to write event", "error", err)
+	}
+}
+
+func errorMessage(value interface {
+	ValueByDiscriminator() (interface{}, error)
+}) string {
+	v, err := value.ValueByDiscriminator()
+	if err != nil {
+		return err.Error()
+	}
+	switch v := v.(type) {
+	case client.ProviderAuthError:
+		return v.Data.Message
+	case client.UnknownError:
+		return v.Data.Message
+	}
+	return "unknown error"
+}
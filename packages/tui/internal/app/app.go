@@ -2,9 +2,11 @@ package app
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"log/slog"
 
@@ -26,8 +28,52 @@ type App struct {
 	Model      *client.ModelInfo
 	Session    *client.SessionInfo
 	Messages   []client.MessageInfo
-	Status     status.Service
-	Commands   commands.Registry
+	// Permissions are the permission requests waiting for an answer,
+	// oldest first
+	Permissions []client.PermissionInfo
+	Status      status.Service
+	Commands    commands.Registry
+
+	startupSession *client.SessionInfo
+	startupPrompt  string
+	startupSend    bool
+	// keepStartupModel stops the --model override from being replaced by
+	// the model of the --session or --continue session
+	keepStartupModel bool
+
+	// providers are the providers listed at startup, to look up the models
+	// of past messages
+	providers []client.ProviderInfo
+	// toolSchemas are the JSON schemas of the tools' input by tool name
+	toolSchemas map[string]map[string]any
+	queue       map[string][]QueuedPrompt
+	queueID     int
+	// turns are the sessions waiting for a turn this app started, other
+	// clients of the server can prompt sessions too
+	turns map[string]bool
+	// diagnostics are the latest diagnostics by file path and language
+	// server ID
+	diagnostics map[string]map[string][]client.LspDiagnostic
+}
+
+// Options are the command line overrides applied when the app starts. They
+// only affect the current run and are never written to the config file.
+type Options struct {
+	// Session is the ID of a session to open.
+	Session string
+	// Continue opens the most recently created session.
+	Continue bool
+	// Model overrides the configured model, as provider/model.
+	Model string
+	// Theme overrides the configured theme.
+	Theme string
+	// Prompt pre-fills the editor.
+	Prompt string
+	// Send submits Prompt right away instead of leaving it in the editor.
+	Send bool
+	// DraftSession is the session of a draft restored after a crash. Unlike
+	// Session it is skipped if the session no longer exists.
+	DraftSession string
 }
 
 type AppInfo struct {
@@ -37,7 +83,7 @@ type AppInfo struct {
 
 var Info AppInfo
 
-func New(ctx context.Context, version string, httpClient *client.ClientWithResponses) (*App, error) {
+func New(ctx context.Context, version string, httpClient *client.ClientWithResponses, opts Options) (*App, error) {
 	err := status.InitService()
 	if err != nil {
 		slog.Error("Failed to initialize status service", "error", err)
@@ -84,6 +130,17 @@ func New(ctx context.Context, version string, httpClient *client.ClientWithRespo
 		return nil, fmt.Errorf("no providers found")
 	}
 
+	// calls of tools the server doesn't list are laid out without a schema
+	toolSchemas := map[string]map[string]any{}
+	toolsResponse, err := httpClient.PostToolListWithResponse(ctx)
+	if err == nil && toolsResponse.JSON200 != nil {
+		for _, tool := range *toolsResponse.JSON200 {
+			toolSchemas[tool.Id] = tool.Parameters
+		}
+	} else {
+		slog.Warn("Failed to list tools", "error", err)
+	}
+
 	appConfigPath := filepath.Join(Info.Path.Config, "config")
 	appConfig, err := config.LoadConfig(appConfigPath)
 	if err != nil {
@@ -106,23 +163,186 @@ func New(ctx context.Context, version string, httpClient *client.ClientWithRespo
 		}
 	}
 
+	if opts.Model != "" {
+		currentProvider, currentModel, err = findModel(providers, opts.Model)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	app := &App{
-		ConfigPath: appConfigPath,
-		Config:     appConfig,
-		Client:     httpClient,
-		Provider:   currentProvider,
-		Model:      currentModel,
-		Session:    &client.SessionInfo{},
-		Messages:   []client.MessageInfo{},
-		Status:     status.GetService(),
-		Commands:   commands.NewCommandRegistry(),
+		ConfigPath:    appConfigPath,
+		Config:        appConfig,
+		Client:        httpClient,
+		Provider:      currentProvider,
+		Model:         currentModel,
+		Session:       &client.SessionInfo{},
+		Messages:      []client.MessageInfo{},
+		Status:        status.GetService(),
+		Commands:      commands.NewCommandRegistry(),
+		providers:     providers,
+		toolSchemas:   toolSchemas,
+		startupPrompt: opts.Prompt,
+		startupSend:   opts.Send,
 	}
 
-	theme.SetTheme(appConfig.Theme)
+	if opts.Session != "" || opts.Continue {
+		session, err := app.findSession(ctx, opts.Session)
+		if err != nil {
+			return nil, err
+		}
+		app.startupSession = session
+		app.keepStartupModel = opts.Model != ""
+	} else if opts.DraftSession != "" {
+		session, err := app.findSession(ctx, opts.DraftSession)
+		if err != nil {
+			slog.Warn("Not reopening the session of the restored draft", "session", opts.DraftSession, "error", err)
+		} else {
+			app.startupSession = session
+			app.keepStartupModel = opts.Model != ""
+		}
+	}
+
+	if opts.Theme != "" {
+		if err := theme.SetTheme(opts.Theme); err != nil {
+			return nil, err
+		}
+	} else {
+		theme.SetTheme(appConfig.Theme)
+	}
 
 	return app, nil
 }
 
+// findModel looks up a model given as provider/model.
+func findModel(providers []client.ProviderInfo, name string) (*client.ProviderInfo, *client.ModelInfo, error) {
+	providerID, modelID, ok := strings.Cut(name, "/")
+	if !ok || providerID == "" || modelID == "" {
+		return nil, nil, fmt.Errorf("invalid model %q, expected provider/model", name)
+	}
+	for _, provider := range providers {
+		if provider.Id != providerID {
+			continue
+		}
+		model, ok := provider.Models[modelID]
+		if !ok {
+			return nil, nil, fmt.Errorf("model %q not found for provider %q", modelID, providerID)
+		}
+		return &provider, &model, nil
+	}
+	return nil, nil, fmt.Errorf("provider %q not found", providerID)
+}
+
+// findSession returns the session with the given ID, or the most recent
+// session if id is empty.
+func (a *App) findSession(ctx context.Context, id string) (*client.SessionInfo, error) {
+	sessions, err := a.ListSessions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		if len(sessions) == 0 {
+			return nil, fmt.Errorf("no session to continue")
+		}
+		return &sessions[0], nil
+	}
+	for _, session := range sessions {
+		if session.Id == id {
+			return &session, nil
+		}
+	}
+	return nil, fmt.Errorf("session %q not found", id)
+}
+
+// SessionModelMsg carries the provider and model a session was last used
+// with, as looked up by RestoreSessionModel. Provider and Model are nil if
+// they are no longer available.
+type SessionModelMsg struct {
+	SessionID  string
+	ProviderID string
+	ModelID    string
+	Provider   *client.ProviderInfo
+	Model      *client.ModelInfo
+}
+
+// RestoreSessionModel returns a command looking up the provider and model used
+// by the last assistant message of the current session, unless the config
+// asks to keep the current model. It returns nil when there is nothing to
+// switch to. The config file is not changed.
+func (a *App) RestoreSessionModel(ctx context.Context) tea.Cmd {
+	if a.keepStartupModel {
+		a.keepStartupModel = false
+		return nil
+	}
+	if a.Config.KeepModel {
+		return nil
+	}
+
+	providerID, modelID := "", ""
+	for i := len(a.Messages) - 1; i >= 0; i-- {
+		if assistant := a.Messages[i].Metadata.Assistant; assistant != nil {
+			providerID, modelID = assistant.ProviderID, assistant.ModelID
+			break
+		}
+	}
+	if providerID == "" || modelID == "" {
+		return nil
+	}
+	if a.Provider != nil && a.Model != nil && a.Provider.Id == providerID && a.Model.Id == modelID {
+		return nil
+	}
+
+	sessionID := a.Session.Id
+	return func() tea.Msg {
+		providers, err := a.ListProviders(ctx)
+		if err != nil {
+			slog.Error("Failed to list providers", "error", err)
+			return nil
+		}
+		msg := SessionModelMsg{SessionID: sessionID, ProviderID: providerID, ModelID: modelID}
+		msg.Provider, msg.Model, _ = findModel(providers, providerID+"/"+modelID)
+		return msg
+	}
+}
+
+// ApplySessionModel switches to the model looked up by RestoreSessionModel,
+// if its session is still the current one.
+func (a *App) ApplySessionModel(msg SessionModelMsg) {
+	if msg.SessionID != a.Session.Id {
+		return
+	}
+	if msg.Provider == nil || msg.Model == nil {
+		current := ""
+		if a.Model != nil {
+			current = a.Model.Name
+		}
+		status.Warn(fmt.Sprintf("This session used %s/%s, which is no longer available. Continuing with %s", msg.ProviderID, msg.ModelID, current))
+		return
+	}
+	a.Provider = msg.Provider
+	a.Model = msg.Model
+	slog.Info("Restored session model", "provider", msg.Provider.Id, "model", msg.Model.Id)
+}
+
+// Startup returns the messages that apply the command line options once the
+// TUI is running: selecting the requested session, then filling in the prompt.
+func (a *App) Startup() tea.Cmd {
+	var cmds []tea.Cmd
+	if a.startupSession != nil {
+		cmds = append(cmds, util.CmdHandler(state.SessionSelectedMsg(a.startupSession)))
+	}
+	if a.startupPrompt != "" {
+		cmds = append(cmds, util.CmdHandler(state.PromptMsg{
+			Text: a.startupPrompt,
+			Send: a.startupSend,
+		}))
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Sequence(cmds...)
+}
+
 func getDefaultModel(response *client.PostProviderListResponse, provider client.ProviderInfo) *client.ModelInfo {
 	if match, ok := response.JSON200.Default[provider.Id]; ok {
 		model := provider.Models[match]
@@ -142,6 +362,37 @@ type Attachment struct {
 	Content  []byte
 }
 
+// FilePart encodes the attachment as a file part with a data URL.
+func (a Attachment) FilePart() client.MessagePart {
+	filename := a.FileName
+	part := client.MessagePart{}
+	part.FromMessagePartFile(client.MessagePartFile{
+		Type:      "file",
+		Filename:  &filename,
+		MediaType: a.MimeType,
+		Url:       "data:" + a.MimeType + ";base64," + base64.StdEncoding.EncodeToString(a.Content),
+	})
+	return part
+}
+
+// MessageParts builds the parts of a chat message: the text, if any,
+// followed by one file part per attachment.
+func MessageParts(text string, attachments []Attachment) []client.MessagePart {
+	parts := []client.MessagePart{}
+	if text != "" {
+		part := client.MessagePart{}
+		part.FromMessagePartText(client.MessagePartText{
+			Type: "text",
+			Text: text,
+		})
+		parts = append(parts, part)
+	}
+	for _, attachment := range attachments {
+		parts = append(parts, attachment.FilePart())
+	}
+	return parts
+}
+
 func (a *App) IsBusy() bool {
 	if len(a.Messages) == 0 {
 		return false
@@ -221,25 +472,33 @@ func (a *App) SendChatMessage(ctx context.Context, text string, attachments []At
 		cmds = append(cmds, util.CmdHandler(state.SessionSelectedMsg(session)))
 	}
 
-	// TODO: Handle attachments when API supports them
-	if len(attachments) > 0 {
-		// For now, ignore attachments
-		// return "", fmt.Errorf("attachments not supported yet")
+	a.sendChat(ctx, a.Session.Id, text, attachments)
+
+	// The actual response will come through SSE
+	// For now, just return success
+	return tea.Batch(cmds...)
+}
+
+func (a *App) sendChat(ctx context.Context, sessionID string, text string, attachments []Attachment) {
+	if len(attachments) > 0 && !a.Model.Attachment {
+		status.Warn(fmt.Sprintf("%s does not support attachments, sending the text only", a.Model.Name))
+		attachments = nil
 	}
+	a.postChat(ctx, sessionID, MessageParts(text, attachments))
+}
 
-	part := client.MessagePart{}
-	part.FromMessagePartText(client.MessagePartText{
-		Type: "text",
-		Text: text,
-	})
-	parts := []client.MessagePart{part}
+// postChat sends the parts of a prompt with the current provider and model.
+// The response comes through the event stream.
+func (a *App) postChat(ctx context.Context, sessionID string, parts []client.MessagePart) {
+	providerID, modelID := a.Provider.Id, a.Model.Id
+	a.startTurn(sessionID)
 
 	go func() {
 		response, err := a.Client.PostSessionChat(ctx, client.PostSessionChatJSONRequestBody{
-			SessionID:  a.Session.Id,
+			SessionID:  sessionID,
 			Parts:      parts,
-			ProviderID: a.Provider.Id,
-			ModelID:    a.Model.Id,
+			ProviderID: providerID,
+			ModelID:    modelID,
 		})
 		if err != nil {
 			slog.Error("Failed to send message", "error", err)
@@ -250,10 +509,6 @@ func (a *App) SendChatMessage(ctx context.Context, text string, attachments []At
 			status.Error(fmt.Sprintf("failed to send message: %d", response.StatusCode))
 		}
 	}()
-
-	// The actual response will come through SSE
-	// For now, just return success
-	return tea.Batch(cmds...)
 }
 
 func (a *App) Cancel(ctx context.Context, sessionID string) error {
@@ -273,6 +528,41 @@ func (a *App) Cancel(ctx context.Context, sessionID string) error {
 	return nil
 }
 
+// ToolSchema returns the JSON schema of a tool's input, or nil if the server
+// didn't list the tool.
+func (a *App) ToolSchema(name string) map[string]any {
+	return a.toolSchemas[name]
+}
+
+// ShareSession creates a shareable link for a session, or returns
+// the existing one.
+func (a *App) ShareSession(ctx context.Context, sessionID string) (*client.SessionInfo, error) {
+	resp, err := a.Client.PostSessionShareWithResponse(ctx, client.PostSessionShareJSONRequestBody{
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != 200 || resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to share session: %d", resp.StatusCode())
+	}
+	return resp.JSON200, nil
+}
+
+// UnshareSession removes the shareable link of a session.
+func (a *App) UnshareSession(ctx context.Context, sessionID string) (*client.SessionInfo, error) {
+	resp, err := a.Client.PostSessionUnshareWithResponse(ctx, client.PostSessionUnshareJSONRequestBody{
+		SessionID: sessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != 200 || resp.JSON200 == nil {
+		return nil, fmt.Errorf("failed to unshare session: %d", resp.StatusCode())
+	}
+	return resp.JSON200, nil
+}
+
 func (a *App) ListSessions(ctx context.Context) ([]client.SessionInfo, error) {
 	resp, err := a.Client.PostSessionListWithResponse(ctx)
 	if err != nil {
@@ -323,3 +613,19 @@ func (a *App) ListProviders(ctx context.Context) ([]client.ProviderInfo, error)
 	providers := *resp.JSON200
 	return providers.Providers, nil
 }
+
+// ListProviderDefaults returns the configured providers along with the ID of
+// each provider's default model, keyed by provider ID.
+func (a *App) ListProviderDefaults(ctx context.Context) ([]client.ProviderInfo, map[string]string, error) {
+	resp, err := a.Client.PostProviderListWithResponse(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode() != 200 {
+		return nil, nil, fmt.Errorf("failed to list providers: %d", resp.StatusCode())
+	}
+	if resp.JSON200 == nil {
+		return []client.ProviderInfo{}, map[string]string{}, nil
+	}
+	return resp.JSON200.Providers, resp.JSON200.Default, nil
+}
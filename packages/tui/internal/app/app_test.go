@@ -0,0 +1,55 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode/pkg/client"
+)
+
+func TestFindModel(t *testing.T) {
+	providers := []client.ProviderInfo{
+		{
+			Id: "anthropic",
+			Models: map[string]client.ModelInfo{
+				"claude-sonnet-4": {Id: "claude-sonnet-4"},
+			},
+		},
+		{
+			Id: "openrouter",
+			Models: map[string]client.ModelInfo{
+				"anthropic/claude-sonnet-4": {Id: "anthropic/claude-sonnet-4"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		model        string
+		wantProvider string
+		wantModel    string
+		wantErr      bool
+	}{
+		{name: "provider and model", model: "anthropic/claude-sonnet-4", wantProvider: "anthropic", wantModel: "claude-sonnet-4"},
+		{name: "model with a slash", model: "openrouter/anthropic/claude-sonnet-4", wantProvider: "openrouter", wantModel: "anthropic/claude-sonnet-4"},
+		{name: "unknown model", model: "anthropic/claude-2", wantErr: true},
+		{name: "unknown provider", model: "openai/gpt-4o", wantErr: true},
+		{name: "no provider", model: "claude-sonnet-4", wantErr: true},
+		{name: "empty provider", model: "/claude-sonnet-4", wantErr: true},
+		{name: "empty model", model: "anthropic/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, model, err := findModel(providers, tt.model)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("findModel(%q) error = %v, wantErr %v", tt.model, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if provider.Id != tt.wantProvider || model.Id != tt.wantModel {
+				t.Errorf("findModel(%q) = %s, %s, want %s, %s", tt.model, provider.Id, model.Id, tt.wantProvider, tt.wantModel)
+			}
+		})
+	}
+}
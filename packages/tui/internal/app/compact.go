@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/status"
+	"github.com/sst/opencode/pkg/client"
+)
+
+const defaultAutoCompactThreshold = 0.8
+
+// CompactFailedMsg is sent when a session could not be compacted, the summary
+// never completes then.
+type CompactFailedMsg struct {
+	SessionID string
+}
+
+// CompactSession summarizes a session with the current provider and model,
+// so later messages only send the summary instead of the whole history.
+func (a *App) CompactSession(ctx context.Context, sessionID string) tea.Cmd {
+	status.Info("Compacting session...")
+	providerID, modelID := a.Provider.Id, a.Model.Id
+	a.startTurn(sessionID)
+
+	return func() tea.Msg {
+		response, err := a.Client.PostSessionSummarize(ctx, client.PostSessionSummarizeJSONRequestBody{
+			SessionID:  sessionID,
+			ProviderID: providerID,
+			ModelID:    modelID,
+		})
+		if err != nil {
+			slog.Error("Failed to compact session", "error", err)
+			status.Error(err.Error())
+			return CompactFailedMsg{SessionID: sessionID}
+		}
+		if response.StatusCode != 200 {
+			slog.Error("Failed to compact session", "error", fmt.Sprintf("failed to compact session: %d", response.StatusCode))
+			status.Error(fmt.Sprintf("failed to compact session: %d", response.StatusCode))
+			return CompactFailedMsg{SessionID: sessionID}
+		}
+		status.Info("Session compacted")
+		return nil
+	}
+}
+
+// CompactFailed ends the turn of a summary that failed, sending the prompts
+// that were queued behind it without the summary.
+func (a *App) CompactFailed(ctx context.Context, sessionID string) tea.Cmd {
+	if !a.turns[sessionID] {
+		return nil
+	}
+	delete(a.turns, sessionID)
+	return a.dispatchQueued(ctx, sessionID)
+}
+
+// TurnCompleted does the follow up work for a session once one of its
+// assistant messages has completed: compacting the session if it is close to
+// the context limit, then sending the next queued prompt. Only turns started
+// by this app are followed up.
+func (a *App) TurnCompleted(ctx context.Context, message client.MessageInfo) tea.Cmd {
+	if message.Role != client.Assistant || message.Metadata.Time.Completed == nil {
+		return nil
+	}
+	sessionID := message.Metadata.SessionID
+	if !a.turns[sessionID] {
+		return nil
+	}
+	delete(a.turns, sessionID)
+
+	if a.shouldAutoCompact(message) {
+		slog.Info("Auto compacting session", "session", sessionID)
+		// queued prompts are sent once the summary has completed, or
+		// failed
+		return a.CompactSession(ctx, sessionID)
+	}
+	return a.dispatchQueued(ctx, sessionID)
+}
+
+// startTurn records that this app is waiting for a turn of the session
+func (a *App) startTurn(sessionID string) {
+	if a.turns == nil {
+		a.turns = map[string]bool{}
+	}
+	a.turns[sessionID] = true
+}
+
+func (a *App) shouldAutoCompact(message client.MessageInfo) bool {
+	assistant := message.Metadata.Assistant
+	if !a.Config.AutoCompact || assistant == nil {
+		return false
+	}
+	if assistant.Summary != nil && *assistant.Summary {
+		return false
+	}
+	if message.Metadata.Error != nil {
+		return false
+	}
+	limit := a.contextLimit(assistant.ProviderID, assistant.ModelID)
+	if limit <= 0 {
+		return false
+	}
+	threshold := a.Config.AutoCompactThreshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = defaultAutoCompactThreshold
+	}
+	return float64(assistant.Tokens.Input) > threshold*limit
+}
+
+// contextLimit returns the context window of the model that answered a
+// message, or 0 if the model is unknown
+func (a *App) contextLimit(providerID, modelID string) float64 {
+	if a.Provider != nil && a.Model != nil && a.Provider.Id == providerID && a.Model.Id == modelID {
+		return float64(a.Model.Limit.Context)
+	}
+	for _, provider := range a.providers {
+		if provider.Id != providerID {
+			continue
+		}
+		if model, ok := provider.Models[modelID]; ok {
+			return float64(model.Limit.Context)
+		}
+	}
+	return 0
+}
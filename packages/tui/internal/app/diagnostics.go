@@ -0,0 +1,112 @@
+package app
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sst/opencode/pkg/client"
+)
+
+// DiagnosticFile is a file with diagnostics from one or more language
+// servers.
+type DiagnosticFile struct {
+	// Path is relative to the project root when the file is inside it.
+	Path        string
+	Diagnostics []client.LspDiagnostic
+	Errors      int
+	Warnings    int
+}
+
+// UpdateDiagnostics replaces the diagnostics a language server reported for
+// a file.
+func (a *App) UpdateDiagnostics(serverID string, path string, diagnostics []client.LspDiagnostic) {
+	if a.diagnostics == nil {
+		a.diagnostics = map[string]map[string][]client.LspDiagnostic{}
+	}
+	servers := a.diagnostics[path]
+	if servers == nil {
+		servers = map[string][]client.LspDiagnostic{}
+		a.diagnostics[path] = servers
+	}
+	if len(diagnostics) == 0 {
+		delete(servers, serverID)
+	} else {
+		servers[serverID] = diagnostics
+	}
+	if len(servers) == 0 {
+		delete(a.diagnostics, path)
+	}
+}
+
+// DiagnosticFiles returns the files with errors or warnings, the files with
+// the most errors first. Information and hints are left out.
+func (a *App) DiagnosticFiles() []DiagnosticFile {
+	var files []DiagnosticFile
+	for path, servers := range a.diagnostics {
+		file := DiagnosticFile{Path: path}
+		if rel, err := filepath.Rel(Info.Path.Root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			file.Path = rel
+		}
+		for _, diagnostics := range servers {
+			for _, diagnostic := range diagnostics {
+				switch diagnostic.Severity {
+				case client.LspDiagnosticSeverityError:
+					file.Errors++
+				case client.LspDiagnosticSeverityWarning:
+					file.Warnings++
+				default:
+					continue
+				}
+				file.Diagnostics = append(file.Diagnostics, diagnostic)
+			}
+		}
+		if len(file.Diagnostics) == 0 {
+			continue
+		}
+		slices.SortFunc(file.Diagnostics, func(a, b client.LspDiagnostic) int {
+			return cmp.Or(
+				cmp.Compare(a.Line, b.Line),
+				cmp.Compare(a.Character, b.Character),
+			)
+		})
+		files = append(files, file)
+	}
+	slices.SortFunc(files, func(a, b DiagnosticFile) int {
+		return cmp.Or(
+			cmp.Compare(b.Errors, a.Errors),
+			cmp.Compare(b.Warnings, a.Warnings),
+			cmp.Compare(a.Path, b.Path),
+		)
+	})
+	return files
+}
+
+// DiagnosticCounts returns the number of errors and warnings in the project.
+func (a *App) DiagnosticCounts() (errors int, warnings int) {
+	for _, file := range a.DiagnosticFiles() {
+		errors += file.Errors
+		warnings += file.Warnings
+	}
+	return errors, warnings
+}
+
+// DiagnosticsPrompt builds a follow up prompt asking the agent to fix the
+// given files' diagnostics.
+func DiagnosticsPrompt(files []DiagnosticFile) string {
+	var b strings.Builder
+	b.WriteString("Fix these errors reported by the language servers:\n")
+	for _, file := range files {
+		fmt.Fprintf(&b, "\n%s\n", file.Path)
+		for _, diagnostic := range file.Diagnostics {
+			fmt.Fprintf(&b, "- %s [%d:%d] %s", strings.ToUpper(string(diagnostic.Severity)), int(diagnostic.Line), int(diagnostic.Character), diagnostic.Message)
+			if diagnostic.Source != nil && *diagnostic.Source != "" {
+				fmt.Fprintf(&b, " (%s)", *diagnostic.Source)
+			}
+			b.WriteString("\n")
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
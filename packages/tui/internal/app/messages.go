@@ -0,0 +1,89 @@
+package app
+
+import (
+	"github.com/sst/opencode/pkg/client"
+)
+
+// UpdateMessage adds a message of the current session, or replaces it if it
+// is already there.
+func (a *App) UpdateMessage(message client.MessageInfo) {
+	for i, m := range a.Messages {
+		if m.Id == message.Id {
+			a.Messages[i] = message
+			return
+		}
+	}
+	a.Messages = append(a.Messages, message)
+}
+
+// UpdateMessagePart patches a single part of a message of the current
+// session. Tool invocations are matched by their tool call ID, other parts
+// replace the last part of the same type in the current step. It reports
+// false if the message isn't loaded.
+func (a *App) UpdateMessagePart(messageID string, part client.MessagePart) bool {
+	idx := -1
+	for i, m := range a.Messages {
+		if m.Id == messageID {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+
+	parts := a.Messages[idx].Parts
+	if i := partIndex(parts, part); i >= 0 {
+		parts[i] = part
+	} else {
+		a.Messages[idx].Parts = append(parts, part)
+	}
+	return true
+}
+
+// partIndex finds the part that an updated part replaces, or -1 if it is new
+func partIndex(parts []client.MessagePart, part client.MessagePart) int {
+	kind, err := part.Discriminator()
+	if err != nil {
+		return -1
+	}
+
+	// every step starts with a part of its own
+	if kind == "step-start" {
+		return -1
+	}
+
+	if kind == "tool-invocation" {
+		id := toolCallID(part)
+		for i, p := range parts {
+			if k, _ := p.Discriminator(); k == kind && toolCallID(p) == id {
+				return i
+			}
+		}
+		return -1
+	}
+
+	for i := len(parts) - 1; i >= 0; i-- {
+		k, _ := parts[i].Discriminator()
+		switch k {
+		case kind:
+			return i
+		case "step-start":
+			// text and reasoning start over in every step
+			return -1
+		}
+	}
+	return -1
+}
+
+func toolCallID(part client.MessagePart) string {
+	invocation, err := part.AsMessagePartToolInvocation()
+	if err != nil {
+		return ""
+	}
+	call, err := invocation.ToolInvocation.AsMessageToolInvocationToolCall()
+	if err != nil {
+		return ""
+	}
+	return call.ToolCallId
+}
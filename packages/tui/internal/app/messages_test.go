@@ -0,0 +1,168 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/sst/opencode/pkg/client"
+)
+
+func textPart(text string) client.MessagePart {
+	part := client.MessagePart{}
+	part.FromMessagePartText(client.MessagePartText{Text: text})
+	return part
+}
+
+func reasoningPart(text string) client.MessagePart {
+	part := client.MessagePart{}
+	part.FromMessagePartReasoning(client.MessagePartReasoning{Text: text})
+	return part
+}
+
+func stepPart() client.MessagePart {
+	part := client.MessagePart{}
+	part.FromMessagePartStepStart(client.MessagePartStepStart{})
+	return part
+}
+
+func toolPart(id, result string) client.MessagePart {
+	invocation := client.MessageToolInvocation{}
+	if result == "" {
+		invocation.FromMessageToolInvocationToolCall(client.MessageToolInvocationToolCall{ToolCallId: id, ToolName: "opencode_bash"})
+	} else {
+		invocation.FromMessageToolInvocationToolResult(client.MessageToolInvocationToolResult{ToolCallId: id, ToolName: "opencode_bash", Result: result})
+	}
+	part := client.MessagePart{}
+	part.FromMessagePartToolInvocation(client.MessagePartToolInvocation{ToolInvocation: invocation})
+	return part
+}
+
+// describe writes a part as kind:content, enough to tell the parts of the
+// tests apart
+func describe(part client.MessagePart) string {
+	kind, _ := part.Discriminator()
+	switch kind {
+	case "text":
+		text, _ := part.AsMessagePartText()
+		return kind + ":" + text.Text
+	case "reasoning":
+		reasoning, _ := part.AsMessagePartReasoning()
+		return kind + ":" + reasoning.Text
+	case "tool-invocation":
+		invocation, _ := part.AsMessagePartToolInvocation()
+		if result, err := invocation.ToolInvocation.AsMessageToolInvocationToolResult(); err == nil && result.State == "result" {
+			return kind + ":" + result.ToolCallId + "=" + result.Result
+		}
+		return kind + ":" + toolCallID(part)
+	}
+	return kind
+}
+
+func TestUpdateMessagePart(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []client.MessagePart
+		part  client.MessagePart
+		want  []string
+	}{
+		{
+			name: "first part",
+			part: textPart("hello"),
+			want: []string{"text:hello"},
+		},
+		{
+			name:  "text replaces the text of the step",
+			parts: []client.MessagePart{stepPart(), textPart("hel")},
+			part:  textPart("hello"),
+			want:  []string{"step-start", "text:hello"},
+		},
+		{
+			name:  "text replaces the last text after a tool call",
+			parts: []client.MessagePart{stepPart(), textPart("hel"), toolPart("call_1", "")},
+			part:  textPart("hello"),
+			want:  []string{"step-start", "text:hello", "tool-invocation:call_1"},
+		},
+		{
+			name:  "text of a new step is added",
+			parts: []client.MessagePart{stepPart(), textPart("first"), stepPart()},
+			part:  textPart("second"),
+			want:  []string{"step-start", "text:first", "step-start", "text:second"},
+		},
+		{
+			name:  "reasoning and text are kept apart",
+			parts: []client.MessagePart{stepPart(), reasoningPart("hmm"), textPart("hello")},
+			part:  reasoningPart("hmm, yes"),
+			want:  []string{"step-start", "reasoning:hmm, yes", "text:hello"},
+		},
+		{
+			name:  "tool result replaces its call",
+			parts: []client.MessagePart{stepPart(), toolPart("call_1", ""), toolPart("call_2", "")},
+			part:  toolPart("call_1", "done"),
+			want:  []string{"step-start", "tool-invocation:call_1=done", "tool-invocation:call_2"},
+		},
+		{
+			name:  "tool call of an earlier step is found",
+			parts: []client.MessagePart{stepPart(), toolPart("call_1", ""), stepPart(), textPart("next")},
+			part:  toolPart("call_1", "done"),
+			want:  []string{"step-start", "tool-invocation:call_1=done", "step-start", "text:next"},
+		},
+		{
+			name:  "new tool call is added",
+			parts: []client.MessagePart{stepPart(), toolPart("call_1", "")},
+			part:  toolPart("call_2", ""),
+			want:  []string{"step-start", "tool-invocation:call_1", "tool-invocation:call_2"},
+		},
+		{
+			name:  "new step is added",
+			parts: []client.MessagePart{stepPart(), textPart("first")},
+			part:  stepPart(),
+			want:  []string{"step-start", "text:first", "step-start"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{Messages: []client.MessageInfo{
+				{Id: "msg_0"},
+				{Id: "msg_1", Parts: tt.parts},
+			}}
+			if !a.UpdateMessagePart("msg_1", tt.part) {
+				t.Fatal("UpdateMessagePart() = false, want true")
+			}
+			var got []string
+			for _, part := range a.Messages[1].Parts {
+				got = append(got, describe(part))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("parts = %v, want %v", got, tt.want)
+			}
+			if len(a.Messages[0].Parts) != 0 {
+				t.Errorf("other message has %d parts, want 0", len(a.Messages[0].Parts))
+			}
+		})
+	}
+}
+
+func TestUpdateMessagePartNotLoaded(t *testing.T) {
+	a := &App{Messages: []client.MessageInfo{{Id: "msg_1"}}}
+	if a.UpdateMessagePart("msg_2", textPart("hello")) {
+		t.Error("UpdateMessagePart() = true for a message that isn't loaded")
+	}
+	if len(a.Messages) != 1 || len(a.Messages[0].Parts) != 0 {
+		t.Errorf("messages changed: %+v", a.Messages)
+	}
+}
+
+func TestUpdateMessage(t *testing.T) {
+	a := &App{}
+	a.UpdateMessage(client.MessageInfo{Id: "msg_1", Role: "user"})
+	a.UpdateMessage(client.MessageInfo{Id: "msg_2", Role: "assistant"})
+	a.UpdateMessage(client.MessageInfo{Id: "msg_1", Role: "assistant"})
+
+	if len(a.Messages) != 2 {
+		t.Fatalf("%d messages, want 2", len(a.Messages))
+	}
+	if a.Messages[0].Id != "msg_1" || a.Messages[0].Role != "assistant" {
+		t.Errorf("first message = %s %s, want the replaced msg_1", a.Messages[0].Id, a.Messages[0].Role)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/sst/opencode/internal/config"
+	"github.com/sst/opencode/internal/status"
+	"github.com/sst/opencode/pkg/client"
+)
+
+// AddPermission queues a permission request from the server. Requests are
+// answered in the order they arrive, a request that is already queued is
+// ignored.
+func (a *App) AddPermission(permission client.PermissionInfo) {
+	for _, p := range a.Permissions {
+		if p.Id == permission.Id && p.SessionID == permission.SessionID {
+			return
+		}
+	}
+	a.Permissions = append(a.Permissions, permission)
+}
+
+// RespondPermission sends the answer to a permission request and removes it
+// from the queue.
+func (a *App) RespondPermission(ctx context.Context, permission client.PermissionInfo, response client.PostPermissionRespondJSONBodyResponse) {
+	for i, p := range a.Permissions {
+		if p.Id == permission.Id && p.SessionID == permission.SessionID {
+			a.Permissions = append(a.Permissions[:i:i], a.Permissions[i+1:]...)
+			break
+		}
+	}
+	slog.Info("Responding to permission", "session", permission.SessionID, "permission", permission.Id, "response", response)
+
+	go func() {
+		resp, err := a.Client.PostPermissionRespond(ctx, client.PostPermissionRespondJSONRequestBody{
+			SessionID:    permission.SessionID,
+			PermissionID: permission.Id,
+			Response:     response,
+		})
+		if err != nil {
+			slog.Error("Failed to respond to permission", "error", err)
+			status.Error(err.Error())
+			return
+		}
+		if resp.StatusCode != 200 {
+			slog.Error("Failed to respond to permission", "error", fmt.Sprintf("failed to respond to permission: %d", resp.StatusCode))
+			status.Error(fmt.Sprintf("failed to respond to permission: %d", resp.StatusCode))
+		}
+	}()
+}
+
+// AnswerByRule responds to a permission request if one of the configured
+// rules matches it, and reports whether it did.
+func (a *App) AnswerByRule(ctx context.Context, permission client.PermissionInfo) bool {
+	rule, ok := a.MatchPermissionRule(permission)
+	if !ok {
+		return false
+	}
+	slog.Info("Permission answered by rule",
+		"session", permission.SessionID,
+		"permission", permission.Id,
+		"tool", permission.Tool,
+		"title", permission.Title,
+		"rule", rule.String(),
+	)
+	response := client.Once
+	if rule.Action == config.PermissionActionDeny {
+		response = client.Reject
+	}
+	a.RespondPermission(ctx, permission, response)
+	return true
+}
+
+// MatchPermissionRule returns the configured rule that applies to a
+// permission request. Deny rules take precedence over allow rules.
+func (a *App) MatchPermissionRule(permission client.PermissionInfo) (config.PermissionRule, bool) {
+	if a.Config == nil {
+		return config.PermissionRule{}, false
+	}
+	var allow *config.PermissionRule
+	for _, rule := range a.Config.Permissions {
+		if rule.Tool != permission.Tool || !matchPermission(rule.Pattern, permission.Metadata) {
+			continue
+		}
+		if rule.Action == config.PermissionActionDeny {
+			return rule, true
+		}
+		if allow == nil && rule.Action == config.PermissionActionAllow {
+			allow = &rule
+		}
+	}
+	if allow != nil {
+		return *allow, true
+	}
+	return config.PermissionRule{}, false
+}
+
+func matchPermission(pattern string, metadata map[string]any) bool {
+	if pattern == "" {
+		return true
+	}
+	if filePath, ok := metadata["filePath"].(string); ok {
+		if rel, err := filepath.Rel(Info.Path.Root, filePath); err == nil && !strings.HasPrefix(rel, "..") {
+			if match, _ := doublestar.Match(pattern, filepath.ToSlash(rel)); match {
+				return true
+			}
+		}
+		match, _ := doublestar.Match(pattern, filepath.ToSlash(filePath))
+		return match
+	}
+	if command, ok := metadata["command"].(string); ok {
+		if match, _ := doublestar.Match(pattern, command); match {
+			return true
+		}
+		// a trailing * also covers arguments with slashes, so rm -rf*
+		// matches rm -rf /tmp
+		if strings.HasSuffix(pattern, "*") {
+			match, _ := doublestar.Match(pattern+"/**", command)
+			return match
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/sst/opencode/internal/config"
+	"github.com/sst/opencode/pkg/client"
+)
+
+func TestMatchPermission(t *testing.T) {
+	Info.Path.Root = "/project"
+	t.Cleanup(func() { Info = AppInfo{} })
+
+	tests := []struct {
+		name     string
+		pattern  string
+		metadata map[string]any
+		want     bool
+	}{
+		{name: "empty pattern matches anything", pattern: "", metadata: map[string]any{}, want: true},
+		{name: "relative path", pattern: "src/**", metadata: map[string]any{"filePath": "/project/src/app/main.go"}, want: true},
+		{name: "relative path outside the pattern", pattern: "src/**", metadata: map[string]any{"filePath": "/project/docs/readme.md"}, want: false},
+		{name: "single star stays in the directory", pattern: "src/*.go", metadata: map[string]any{"filePath": "/project/src/app/main.go"}, want: false},
+		{name: "extension anywhere", pattern: "**/*.md", metadata: map[string]any{"filePath": "/project/docs/guide/intro.md"}, want: true},
+		{name: "absolute pattern", pattern: "/tmp/**", metadata: map[string]any{"filePath": "/tmp/scratch/out.txt"}, want: true},
+		{name: "outside the project is not relative", pattern: "tmp/**", metadata: map[string]any{"filePath": "/tmp/out.txt"}, want: false},
+		{name: "sibling with the root as prefix", pattern: "src/**", metadata: map[string]any{"filePath": "/project-other/src/main.go"}, want: false},
+		{name: "command", pattern: "go test*", metadata: map[string]any{"command": "go test"}, want: true},
+		{name: "command with arguments", pattern: "go test*", metadata: map[string]any{"command": "go test -run TestX"}, want: true},
+		{name: "trailing star covers slashes", pattern: "rm -rf*", metadata: map[string]any{"command": "rm -rf /tmp/build"}, want: true},
+		{name: "exact command without star", pattern: "git status", metadata: map[string]any{"command": "git status ./src"}, want: false},
+		{name: "other command", pattern: "go test*", metadata: map[string]any{"command": "go build ./..."}, want: false},
+		{name: "no path or command", pattern: "*", metadata: map[string]any{"url": "https://example.com"}, want: false},
+		{name: "path of the wrong type", pattern: "*", metadata: map[string]any{"filePath": 3}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchPermission(tt.pattern, tt.metadata); got != tt.want {
+				t.Errorf("matchPermission(%q, %v) = %v, want %v", tt.pattern, tt.metadata, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchPermissionRule(t *testing.T) {
+	Info.Path.Root = "/project"
+	t.Cleanup(func() { Info = AppInfo{} })
+
+	rules := []config.PermissionRule{
+		{Tool: "opencode_edit", Pattern: "src/**", Action: config.PermissionActionAllow},
+		{Tool: "opencode_edit", Pattern: "src/secrets/**", Action: config.PermissionActionDeny},
+		{Tool: "opencode_bash", Pattern: "go *", Action: config.PermissionActionAllow},
+		{Tool: "opencode_bash", Action: config.PermissionActionAllow},
+		{Tool: "opencode_bash", Pattern: "rm *", Action: config.PermissionActionDeny},
+	}
+
+	tests := []struct {
+		name       string
+		tool       string
+		metadata   map[string]any
+		wantAction string
+		wantOK     bool
+	}{
+		{name: "allowed path", tool: "opencode_edit", metadata: map[string]any{"filePath": "/project/src/main.go"}, wantAction: "allow", wantOK: true},
+		{name: "deny takes precedence", tool: "opencode_edit", metadata: map[string]any{"filePath": "/project/src/secrets/key.pem"}, wantAction: "deny", wantOK: true},
+		{name: "no rule", tool: "opencode_edit", metadata: map[string]any{"filePath": "/project/docs/readme.md"}, wantOK: false},
+		{name: "rule of another tool", tool: "opencode_write", metadata: map[string]any{"filePath": "/project/src/main.go"}, wantOK: false},
+		{name: "deny listed after allow", tool: "opencode_bash", metadata: map[string]any{"command": "rm -r build"}, wantAction: "deny", wantOK: true},
+		{name: "rule without pattern", tool: "opencode_bash", metadata: map[string]any{"command": "ls"}, wantAction: "allow", wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{Config: &config.Config{Permissions: rules}}
+			rule, ok := a.MatchPermissionRule(client.PermissionInfo{Tool: tt.tool, Metadata: tt.metadata})
+			if ok != tt.wantOK {
+				t.Fatalf("MatchPermissionRule() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if rule.Action != tt.wantAction {
+				t.Errorf("MatchPermissionRule() = %v, want action %q", rule, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestAddPermission(t *testing.T) {
+	a := &App{}
+	a.AddPermission(client.PermissionInfo{Id: "call_1", SessionID: "ses_1"})
+	a.AddPermission(client.PermissionInfo{Id: "call_2", SessionID: "ses_1"})
+	a.AddPermission(client.PermissionInfo{Id: "call_1", SessionID: "ses_1"})
+	a.AddPermission(client.PermissionInfo{Id: "call_1", SessionID: "ses_2"})
+
+	want := []string{"ses_1/call_1", "ses_1/call_2", "ses_2/call_1"}
+	if len(a.Permissions) != len(want) {
+		t.Fatalf("queued %d permissions, want %d", len(a.Permissions), len(want))
+	}
+	for i, p := range a.Permissions {
+		if got := p.SessionID + "/" + p.Id; got != want[i] {
+			t.Errorf("permission %d = %s, want %s", i, got, want[i])
+		}
+	}
+}
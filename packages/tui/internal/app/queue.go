@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/sst/opencode/internal/state"
+	"github.com/sst/opencode/internal/util"
+)
+
+// QueuedPrompt is a prompt submitted while the agent was busy. It is sent
+// once the current turn of its session has finished.
+type QueuedPrompt struct {
+	ID          int
+	Text        string
+	Attachments []Attachment
+}
+
+// Enqueue adds a prompt to the end of the session's outbox.
+func (a *App) Enqueue(sessionID string, text string, attachments []Attachment) tea.Cmd {
+	if a.queue == nil {
+		a.queue = map[string][]QueuedPrompt{}
+	}
+	a.queueID++
+	a.queue[sessionID] = append(a.queue[sessionID], QueuedPrompt{
+		ID:          a.queueID,
+		Text:        text,
+		Attachments: attachments,
+	})
+	return util.CmdHandler(state.QueueUpdatedMsg{SessionID: sessionID})
+}
+
+// Queued returns the prompts waiting to be sent for the session, oldest first.
+func (a *App) Queued(sessionID string) []QueuedPrompt {
+	return a.queue[sessionID]
+}
+
+// RemoveQueued drops a prompt from the session's outbox, returning it if it
+// was still queued.
+func (a *App) RemoveQueued(sessionID string, id int) (QueuedPrompt, bool) {
+	prompts := a.queue[sessionID]
+	idx := slices.IndexFunc(prompts, func(p QueuedPrompt) bool { return p.ID == id })
+	if idx < 0 {
+		return QueuedPrompt{}, false
+	}
+	prompt := prompts[idx]
+	a.queue[sessionID] = slices.Delete(prompts, idx, idx+1)
+	return prompt, true
+}
+
+// dispatchQueued sends the next queued prompt of a session.
+func (a *App) dispatchQueued(ctx context.Context, sessionID string) tea.Cmd {
+	prompts := a.queue[sessionID]
+	if len(prompts) == 0 {
+		return nil
+	}
+	next := prompts[0]
+	a.queue[sessionID] = prompts[1:]
+	a.sendChat(ctx, sessionID, next.Text, next.Attachments)
+	return util.CmdHandler(state.QueueUpdatedMsg{SessionID: sessionID})
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/sst/opencode/internal/status"
+	"github.com/sst/opencode/pkg/client"
+)
+
+// RetryLastPrompt sends the last prompt of the current session again, with
+// the current provider and model. Attachments are dropped if the model
+// doesn't support them.
+func (a *App) RetryLastPrompt(ctx context.Context) error {
+	for i := len(a.Messages) - 1; i >= 0; i-- {
+		message := a.Messages[i]
+		if message.Role != client.User {
+			continue
+		}
+		parts := message.Parts
+		if !a.Model.Attachment {
+			parts = slices.DeleteFunc(slices.Clone(parts), func(part client.MessagePart) bool {
+				kind, _ := part.Discriminator()
+				return kind == "file"
+			})
+			if len(parts) < len(message.Parts) {
+				status.Warn(fmt.Sprintf("%s does not support attachments, sending the text only", a.Model.Name))
+			}
+		}
+		a.postChat(ctx, a.Session.Id, parts)
+		return nil
+	}
+	return fmt.Errorf("there is no prompt to retry")
+}
@@ -59,6 +59,43 @@ func NewCommandRegistry() Registry {
 				key.WithKeys("f5", "super+t"),
 			),
 		},
+		"queue": {
+			Name:        "queue",
+			Description: "edit queued prompts",
+			KeyBinding: key.NewBinding(
+				key.WithKeys("f6", "super+u"),
+			),
+		},
+		"diagnostics": {
+			Name:        "diagnostics",
+			Description: "show diagnostics",
+			KeyBinding: key.NewBinding(
+				key.WithKeys("f7", "super+d"),
+			),
+		},
+		"thinking": {
+			Name:        "thinking",
+			Description: "toggle thinking",
+			KeyBinding: key.NewBinding(
+				key.WithKeys("f8", "super+k"),
+			),
+		},
+		"compact": {
+			Name:        "compact",
+			Description: "compact the session",
+		},
+		"permissions": {
+			Name:        "permissions",
+			Description: "edit permission rules",
+		},
+		"share": {
+			Name:        "share",
+			Description: "share session",
+		},
+		"unshare": {
+			Name:        "unshare",
+			Description: "unshare session",
+		},
 		"quit": {
 			Name:        "quit",
 			Description: "quit",
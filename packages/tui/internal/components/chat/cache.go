@@ -1,28 +1,54 @@
 package chat
 
 import (
+	"container/list"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"sync"
+
+	"github.com/sst/opencode/internal/theme"
 )
 
-// MessageCache caches rendered messages to avoid re-rendering
+// defaultCacheBytes is the default budget of the message cache
+const defaultCacheBytes = 32 << 20
+
+// MessageCache caches rendered messages to avoid re-rendering. The least
+// recently used entries are dropped once the cached content exceeds the
+// byte budget.
 type MessageCache struct {
-	mu    sync.RWMutex
-	cache map[string]string
+	mu       sync.Mutex
+	maxBytes int
+	bytes    int
+	order    *list.List
+	cache    map[string]*list.Element
 }
 
-// NewMessageCache creates a new message cache
+type cacheEntry struct {
+	key     string
+	content string
+}
+
+// NewMessageCache creates a new message cache with the default budget
 func NewMessageCache() *MessageCache {
+	return NewMessageCacheWithBudget(defaultCacheBytes)
+}
+
+// NewMessageCacheWithBudget creates a new message cache holding up to
+// maxBytes of keys and content
+func NewMessageCacheWithBudget(maxBytes int) *MessageCache {
 	return &MessageCache{
-		cache: make(map[string]string),
+		maxBytes: maxBytes,
+		order:    list.New(),
+		cache:    make(map[string]*list.Element),
 	}
 }
 
-// generateKey creates a unique key for a message based on its content and rendering parameters
+// generateKey creates a unique key for a message based on its content and rendering parameters.
+// The current theme is part of every key, so switching themes doesn't need the cache cleared.
 func (c *MessageCache) GenerateKey(params ...any) string {
 	h := sha256.New()
+	h.Write([]byte(theme.CurrentThemeName()))
 	for _, param := range params {
 		h.Write(fmt.Appendf(nil, ":%v", param))
 	}
@@ -31,18 +57,38 @@ func (c *MessageCache) GenerateKey(params ...any) string {
 
 // Get retrieves a cached rendered message
 func (c *MessageCache) Get(key string) (string, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	content, exists := c.cache[key]
-	return content, exists
+	element, exists := c.cache[key]
+	if !exists {
+		return "", false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*cacheEntry).content, true
 }
 
 // Set stores a rendered message in the cache
 func (c *MessageCache) Set(key string, content string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.cache[key] = content
+
+	if element, exists := c.cache[key]; exists {
+		entry := element.Value.(*cacheEntry)
+		c.bytes += len(content) - len(entry.content)
+		entry.content = content
+		c.order.MoveToFront(element)
+	} else {
+		c.cache[key] = c.order.PushFront(&cacheEntry{key: key, content: content})
+		c.bytes += len(key) + len(content)
+	}
+
+	// keep the entry just stored even if it is over budget on its own
+	for c.bytes > c.maxBytes && c.order.Len() > 1 {
+		entry := c.order.Remove(c.order.Back()).(*cacheEntry)
+		delete(c.cache, entry.key)
+		c.bytes -= len(entry.key) + len(entry.content)
+	}
 }
 
 // Clear removes all entries from the cache
@@ -50,13 +96,23 @@ func (c *MessageCache) Clear() {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.cache = make(map[string]string)
+	c.order.Init()
+	c.cache = make(map[string]*list.Element)
+	c.bytes = 0
 }
 
 // Size returns the number of cached entries
 func (c *MessageCache) Size() int {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
 	return len(c.cache)
 }
+
+// Bytes returns the size of the cached keys and content
+func (c *MessageCache) Bytes() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.bytes
+}
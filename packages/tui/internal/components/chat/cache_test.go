@@ -0,0 +1,131 @@
+package chat
+
+import (
+	"slices"
+	"testing"
+)
+
+// op is a step run against the cache: a Set when content is given, a Get
+// otherwise
+type op struct {
+	key     string
+	content string
+}
+
+func set(key, content string) op { return op{key: key, content: content} }
+func get(key string) op          { return op{key: key} }
+
+func TestMessageCacheEviction(t *testing.T) {
+	// every entry below is a one byte key and four bytes of content, a
+	// budget of 15 holds three of them
+	tests := []struct {
+		name      string
+		budget    int
+		ops       []op
+		wantKeys  []string
+		wantBytes int
+	}{
+		{
+			name:      "under budget",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc")},
+			wantKeys:  []string{"a", "b", "c"},
+			wantBytes: 15,
+		},
+		{
+			name:      "oldest is evicted first",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), set("d", "dddd")},
+			wantKeys:  []string{"b", "c", "d"},
+			wantBytes: 15,
+		},
+		{
+			name:      "get makes an entry recent",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), get("a"), set("d", "dddd")},
+			wantKeys:  []string{"a", "c", "d"},
+			wantBytes: 15,
+		},
+		{
+			name:      "set of an existing key makes it recent",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), set("a", "AAAA"), set("d", "dddd")},
+			wantKeys:  []string{"a", "c", "d"},
+			wantBytes: 15,
+		},
+		{
+			name:      "get of a missing key changes nothing",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), get("x"), set("d", "dddd")},
+			wantKeys:  []string{"b", "c", "d"},
+			wantBytes: 15,
+		},
+		{
+			name:      "larger entry evicts several",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), set("d", "ddddddddd")},
+			wantKeys:  []string{"c", "d"},
+			wantBytes: 15,
+		},
+		{
+			name:      "growing an entry evicts the oldest",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbb"), set("c", "cccc"), set("c", "cccccc")},
+			wantKeys:  []string{"b", "c"},
+			wantBytes: 12,
+		},
+		{
+			name:      "entry over budget on its own is kept",
+			budget:    15,
+			ops:       []op{set("a", "aaaa"), set("b", "bbbbbbbbbbbbbbbbbbbb")},
+			wantKeys:  []string{"b"},
+			wantBytes: 21,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewMessageCacheWithBudget(tt.budget)
+			for _, op := range tt.ops {
+				if op.content == "" {
+					cache.Get(op.key)
+				} else {
+					cache.Set(op.key, op.content)
+				}
+			}
+
+			var keys []string
+			for _, key := range []string{"a", "b", "c", "d"} {
+				if _, ok := cache.Get(key); ok {
+					keys = append(keys, key)
+				}
+			}
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("cached keys = %v, want %v", keys, tt.wantKeys)
+			}
+			if cache.Size() != len(tt.wantKeys) {
+				t.Errorf("Size() = %d, want %d", cache.Size(), len(tt.wantKeys))
+			}
+			if cache.Bytes() != tt.wantBytes {
+				t.Errorf("Bytes() = %d, want %d", cache.Bytes(), tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestMessageCacheGetUpdatedContent(t *testing.T) {
+	cache := NewMessageCacheWithBudget(100)
+	cache.Set("a", "first")
+	cache.Set("a", "second")
+	if content, ok := cache.Get("a"); !ok || content != "second" {
+		t.Errorf("Get() = %q, %v, want %q, true", content, ok, "second")
+	}
+	if cache.Bytes() != len("a")+len("second") {
+		t.Errorf("Bytes() = %d, want %d", cache.Bytes(), len("a")+len("second"))
+	}
+
+	cache.Clear()
+	if _, ok := cache.Get("a"); ok || cache.Size() != 0 || cache.Bytes() != 0 {
+		t.Errorf("after Clear() Size() = %d, Bytes() = %d", cache.Size(), cache.Bytes())
+	}
+}
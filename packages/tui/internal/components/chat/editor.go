@@ -5,6 +5,7 @@ import (
 	"log/slog"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/v2/key"
@@ -17,6 +18,7 @@ import (
 	"github.com/sst/opencode/internal/components/dialog"
 	"github.com/sst/opencode/internal/image"
 	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/state"
 	"github.com/sst/opencode/internal/status"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
@@ -102,6 +104,20 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case dialog.ThemeChangedMsg:
 		m.textarea = createTextArea(&m.textarea)
+	case state.PromptMsg:
+		m.textarea.SetValue(msg.Text)
+		if msg.Send {
+			return m, m.send()
+		}
+		return m, nil
+	case dialog.EditQueuedPromptMsg:
+		value := msg.Prompt.Text
+		if current := m.textarea.Value(); current != "" {
+			value = current + "\n" + value
+		}
+		m.textarea.SetValue(value)
+		m.attachments = append(m.attachments, msg.Prompt.Attachments...)
+		return m, nil
 	case dialog.CompletionSelectedMsg:
 		if msg.IsCommand {
 			// Execute the command directly
@@ -139,27 +155,21 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.attachments = nil
 			return m, nil
 		}
-		// if m.deleteMode && len(msg.Runes) > 0 && unicode.IsDigit(msg.Runes[0]) {
-		// 	num := int(msg.Runes[0] - '0')
-		// 	m.deleteMode = false
-		// 	if num < 10 && len(m.attachments) > num {
-		// 		if num == 0 {
-		// 			m.attachments = m.attachments[num+1:]
-		// 		} else {
-		// 			m.attachments = slices.Delete(m.attachments, num, num+1)
-		// 		}
-		// 		return m, nil
-		// 	}
-		// }
+		if m.deleteMode {
+			if key := msg.String(); len(key) == 1 && key[0] >= '0' && key[0] <= '9' {
+				num := int(key[0] - '0')
+				m.deleteMode = false
+				if num < len(m.attachments) {
+					m.attachments = slices.Delete(m.attachments, num, num+1)
+				}
+				return m, nil
+			}
+		}
 		if key.Matches(msg, messageKeys.PageUp) || key.Matches(msg, messageKeys.PageDown) ||
 			key.Matches(msg, messageKeys.HalfPageUp) || key.Matches(msg, messageKeys.HalfPageDown) {
 			return m, nil
 		}
 		if key.Matches(msg, editorMaps.OpenEditor) {
-			if m.app.IsBusy() {
-				status.Warn("Agent is working, please wait...")
-				return m, nil
-			}
 			value := m.textarea.Value()
 			m.textarea.Reset()
 			return m, m.openEditor(value)
@@ -176,6 +186,13 @@ func (m *editorComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, cmd
 			}
 			if len(imageBytes) != 0 {
+				if len(m.attachments) >= maxAttachments {
+					status.Warn(fmt.Sprintf("You can attach up to %d files", maxAttachments))
+					return m, cmd
+				}
+				if m.app.Model != nil && !m.app.Model.Attachment {
+					status.Warn(fmt.Sprintf("%s does not support attachments", m.app.Model.Name))
+				}
 				attachmentName := fmt.Sprintf("clipboard-image-%d", len(m.attachments))
 				attachment := app.Attachment{FilePath: attachmentName, FileName: attachmentName, Content: imageBytes, MimeType: "image/png"}
 				m.attachments = append(m.attachments, attachment)
@@ -284,6 +301,9 @@ func (m *editorComponent) View() string {
 	hint := base("enter") + muted(" send   ") + base("shift") + muted("+") + base("enter") + muted(" newline")
 	if m.app.IsBusy() {
 		hint = muted("working") + m.spinner.View() + muted("  ") + base("esc") + muted(" interrupt")
+		if queued := len(m.app.Queued(m.app.Session.Id)); queued > 0 {
+			hint += muted(fmt.Sprintf("  %d queued • ", queued)) + base("/queue")
+		}
 	}
 
 	model := ""
@@ -299,8 +319,7 @@ func (m *editorComponent) View() string {
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Top,
-		// m.attachmentsContent(),
-		"",
+		m.attachmentsContent(),
 		textarea,
 		info,
 	)
@@ -376,7 +395,7 @@ func (m *editorComponent) send() tea.Cmd {
 	}
 
 	m.attachments = nil
-	if value == "" {
+	if value == "" && len(attachments) == 0 {
 		return nil
 	}
 
@@ -0,0 +1,293 @@
+package chat
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// jsonToolRenderer draws tools that don't have a renderer of their own, like
+// MCP tools. Short arguments go in the title, structured arguments and
+// results that parse as JSON are laid out by their JSON type: objects as
+// keys and values, arrays as lists, nested values indented. When the server
+// lists the tool, its input schema orders the arguments, picks the title and
+// hides arguments left at their default.
+type jsonToolRenderer struct {
+	toolLabel
+	// titleKey is the argument shown first in the title, the first
+	// required string argument or the first argument by name if empty
+	titleKey string
+}
+
+func (r jsonToolRenderer) Title(call ToolCall) string {
+	schema := parseToolSchema(call.Schema)
+	keys := []string{}
+	for _, key := range schema.order(call.Args) {
+		value := call.Args[key]
+		if schema.inline(key, value) && !schema.isDefault(key, value) {
+			keys = append(keys, key)
+		}
+	}
+	titleKey := r.titleKey
+	if !slices.Contains(keys, titleKey) && len(keys) > 0 {
+		// prefer a string, numbers and flags read better as key=value
+		titleKey = keys[0]
+		for _, key := range keys {
+			if _, ok := call.Args[key].(string); ok {
+				titleKey = key
+				break
+			}
+		}
+	}
+	return fmt.Sprintf("%s: %s", r.name, renderArgList(call.Args, keys, titleKey))
+}
+
+func (r jsonToolRenderer) Body(call ToolCall) string {
+	schema := parseToolSchema(call.Schema)
+	lines := []string{}
+	structured := []string{}
+	for _, key := range schema.order(call.Args) {
+		value := call.Args[key]
+		if !schema.inline(key, value) && !schema.isDefault(key, value) {
+			structured = append(structured, key)
+		}
+	}
+	if len(structured) > 0 {
+		// keep room for the result below the arguments
+		args := call.Truncate(strings.Join(jsonFields(call.Args, structured, ""), "\n"), 4)
+		lines = append(lines, args)
+	}
+
+	result, ok := parseJSON(call.Result)
+	if !ok {
+		if len(lines) == 0 {
+			return ""
+		}
+		if call.Result != "" {
+			lines = append(lines, "", call.Result)
+		}
+	} else {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, jsonLines(result, "")...)
+	}
+
+	body := call.Truncate(strings.Join(lines, "\n"), 10)
+	return renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+}
+
+func (r jsonToolRenderer) Summary(call ToolCall) string {
+	result, ok := parseJSON(call.Result)
+	if !ok {
+		return countLines(call.Result)
+	}
+	switch result := result.(type) {
+	case []any:
+		if len(result) == 1 {
+			return "1 item"
+		}
+		return fmt.Sprintf("%d items", len(result))
+	case map[string]any:
+		if len(result) == 1 {
+			return "1 field"
+		}
+		return fmt.Sprintf("%d fields", len(result))
+	}
+	return ""
+}
+
+// toolSchema is the part of the JSON schema of a tool's input that lays out
+// its arguments. A nil schema lays them out by their values alone.
+type toolSchema struct {
+	// types are the JSON types of the properties
+	types map[string]string
+	// defaults are the default values of the properties that have one
+	defaults map[string]any
+	required []string
+}
+
+func parseToolSchema(schema map[string]any) *toolSchema {
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	result := &toolSchema{types: map[string]string{}, defaults: map[string]any{}}
+	for key, property := range properties {
+		property, ok := property.(map[string]any)
+		if !ok {
+			continue
+		}
+		result.types[key] = schemaType(property["type"])
+		if value, ok := property["default"]; ok {
+			result.defaults[key] = value
+		}
+	}
+	required, _ := schema["required"].([]any)
+	for _, key := range required {
+		if key, ok := key.(string); ok {
+			result.required = append(result.required, key)
+		}
+	}
+	return result
+}
+
+// schemaType returns the JSON type of a schema property, the first one other
+// than null when several are allowed
+func schemaType(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case []any:
+		for _, kind := range value {
+			if kind, ok := kind.(string); ok && kind != "null" {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// order returns the names of the arguments that are set, the required ones
+// first in the order of the schema, then the others by name
+func (s *toolSchema) order(args map[string]any) []string {
+	keys := []string{}
+	if s != nil {
+		for _, key := range s.required {
+			if args[key] != nil {
+				keys = append(keys, key)
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(args)) {
+		if args[key] != nil && !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// inline reports whether an argument goes in the title rather than the body
+func (s *toolSchema) inline(key string, value any) bool {
+	if s != nil {
+		switch s.types[key] {
+		case "object", "array":
+			return false
+		}
+	}
+	return isScalar(value)
+}
+
+// isDefault reports whether an argument is set to the default of the schema
+func (s *toolSchema) isDefault(key string, value any) bool {
+	if s == nil {
+		return false
+	}
+	defaultValue, ok := s.defaults[key]
+	return ok && reflect.DeepEqual(value, defaultValue)
+}
+
+// parseJSON decodes a result that is a JSON object or array
+func parseJSON(content string) (any, bool) {
+	content = strings.TrimSpace(content)
+	if !strings.HasPrefix(content, "{") && !strings.HasPrefix(content, "[") {
+		return nil, false
+	}
+	var value any
+	if err := json.Unmarshal([]byte(content), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
+
+// isScalar reports whether a value fits on the title line
+func isScalar(value any) bool {
+	switch value := value.(type) {
+	case map[string]any, []any:
+		return false
+	case string:
+		return !strings.Contains(value, "\n")
+	}
+	return true
+}
+
+// jsonLines lays out a decoded JSON value, one line per scalar, with object
+// keys highlighted
+func jsonLines(value any, indent string) []string {
+	switch value := value.(type) {
+	case map[string]any:
+		if len(value) == 0 {
+			return []string{indent + "{}"}
+		}
+		return jsonFields(value, slices.Sorted(maps.Keys(value)), indent)
+	case []any:
+		if len(value) == 0 {
+			return []string{indent + "[]"}
+		}
+		lines := []string{}
+		for _, item := range value {
+			nested := jsonLines(item, indent+"  ")
+			nested[0] = indent + "- " + strings.TrimPrefix(nested[0], indent+"  ")
+			lines = append(lines, nested...)
+		}
+		return lines
+	case string:
+		lines := []string{}
+		for line := range strings.SplitSeq(value, "\n") {
+			lines = append(lines, indent+line)
+		}
+		return lines
+	default:
+		return []string{indent + jsonScalar(value)}
+	}
+}
+
+// jsonFields lays out the fields of an object named by keys, in that order
+func jsonFields(value map[string]any, keys []string, indent string) []string {
+	t := theme.CurrentTheme()
+	keyStyle := styles.BaseStyle().Background(t.BackgroundSubtle()).Foreground(t.Text())
+
+	lines := []string{}
+	for _, key := range keys {
+		label := indent + keyStyle.Render(key+":")
+		nested := jsonLines(value[key], indent+"  ")
+		if isBlock(value[key]) {
+			lines = append(lines, label)
+			lines = append(lines, nested...)
+		} else {
+			lines = append(lines, label+" "+strings.TrimPrefix(nested[0], indent+"  "))
+		}
+	}
+	return lines
+}
+
+// isBlock reports whether a value is laid out below its key rather than
+// after it
+func isBlock(value any) bool {
+	switch value := value.(type) {
+	case map[string]any:
+		return len(value) > 0
+	case []any:
+		return len(value) > 0
+	case string:
+		return strings.Contains(value, "\n")
+	}
+	return false
+}
+
+func jsonScalar(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,148 @@
+package chat
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func decode(t *testing.T, text string) map[string]any {
+	t.Helper()
+	var value map[string]any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func TestToolSchema(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"properties": {
+			"query": {"type": "string"},
+			"limit": {"type": "number", "default": 10},
+			"filters": {"type": ["object", "null"]},
+			"tags": {"type": "array"},
+			"verbose": {"type": "boolean", "default": false},
+			"broken": "not a property"
+		},
+		"required": ["query", "tags", 3]
+	}`
+
+	tests := []struct {
+		name       string
+		schema     string
+		args       string
+		wantOrder  []string
+		wantInline []string
+		wantDef    []string
+	}{
+		{
+			name:       "required first, then by name",
+			schema:     schema,
+			args:       `{"verbose": true, "limit": 5, "tags": ["a"], "query": "q"}`,
+			wantOrder:  []string{"query", "tags", "limit", "verbose"},
+			wantInline: []string{"query", "limit", "verbose"},
+		},
+		{
+			name:       "defaults are reported",
+			schema:     schema,
+			args:       `{"query": "q", "limit": 10, "verbose": false}`,
+			wantOrder:  []string{"query", "limit", "verbose"},
+			wantInline: []string{"query", "limit", "verbose"},
+			wantDef:    []string{"limit", "verbose"},
+		},
+		{
+			name:       "structured types go in the body even when empty",
+			schema:     schema,
+			args:       `{"query": "q", "filters": {}, "tags": []}`,
+			wantOrder:  []string{"query", "tags", "filters"},
+			wantInline: []string{"query"},
+		},
+		{
+			name:       "null arguments are left out",
+			schema:     schema,
+			args:       `{"query": null, "limit": 3}`,
+			wantOrder:  []string{"limit"},
+			wantInline: []string{"limit"},
+		},
+		{
+			name:       "no schema lays out by value",
+			schema:     `{}`,
+			args:       `{"b": "x", "a": {"nested": true}, "c": 1}`,
+			wantOrder:  []string{"a", "b", "c"},
+			wantInline: []string{"b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := parseToolSchema(decode(t, tt.schema))
+			args := decode(t, tt.args)
+
+			order := schema.order(args)
+			if !slices.Equal(order, tt.wantOrder) {
+				t.Errorf("order = %v, want %v", order, tt.wantOrder)
+			}
+			var inline, defaults []string
+			for _, key := range order {
+				if schema.inline(key, args[key]) {
+					inline = append(inline, key)
+				}
+				if schema.isDefault(key, args[key]) {
+					defaults = append(defaults, key)
+				}
+			}
+			if !slices.Equal(inline, tt.wantInline) {
+				t.Errorf("inline = %v, want %v", inline, tt.wantInline)
+			}
+			if !slices.Equal(defaults, tt.wantDef) {
+				t.Errorf("defaults = %v, want %v", defaults, tt.wantDef)
+			}
+		})
+	}
+}
+
+func TestSchemaType(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{name: "string", value: "object", want: "object"},
+		{name: "nullable", value: []any{"null", "array"}, want: "array"},
+		{name: "only null", value: []any{"null"}, want: ""},
+		{name: "missing", value: nil, want: ""},
+		{name: "wrong type", value: 3.0, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schemaType(tt.value); got != tt.want {
+				t.Errorf("schemaType(%v) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		content string
+		wantOK  bool
+	}{
+		{content: `{"a": 1}`, wantOK: true},
+		{content: "  [1, 2]\n", wantOK: true},
+		{content: `"a string"`, wantOK: false},
+		{content: `42`, wantOK: false},
+		{content: `{"a": `, wantOK: false},
+		{content: `plain text`, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if _, ok := parseJSON(tt.content); ok != tt.wantOK {
+				t.Errorf("parseJSON(%q) ok = %v, want %v", tt.content, ok, tt.wantOK)
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package chat
 import (
 	"fmt"
 	"log/slog"
+	"maps"
+	"net/url"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -13,13 +15,11 @@ import (
 	"github.com/charmbracelet/lipgloss/v2/compat"
 	"github.com/charmbracelet/x/ansi"
 	"github.com/sst/opencode/internal/app"
-	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/image"
 	"github.com/sst/opencode/internal/layout"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/pkg/client"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 )
 
 func toMarkdown(content string, width int, backgroundColor compat.AdaptiveColor) string {
@@ -238,19 +238,223 @@ func renderText(message client.MessageInfo, text string, author string) string {
 	return ""
 }
 
+// renderReasoning renders a reasoning part as a dimmed thinking block, which
+// only shows its header while collapsed. tokens is left out of the header
+// when it is zero.
+func renderReasoning(text string, tokens float32, expanded bool, streaming bool) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width
+	muted := styles.BaseStyle().
+		Background(t.BackgroundSubtle()).
+		Foreground(t.TextMuted())
+
+	header := "Thinking"
+	if streaming {
+		header = "Thinking..."
+	}
+	if tokens > 0 {
+		header = fmt.Sprintf("%s · %s tokens", header, formatTokenCount(tokens))
+	}
+	if !expanded {
+		header = "▸ " + header
+		return renderContentBlock(
+			muted.Render(header)+muted.Faint(true).Render("  ctrl+o expand"),
+			WithBorderColor(t.TextMuted()),
+			WithPaddingTop(0),
+			WithPaddingBottom(0),
+		)
+	}
+
+	body := muted.
+		Italic(true).
+		Width(width - 6). // -6 for the border and padding
+		Render(strings.TrimSpace(text))
+	return renderContentBlock(
+		lipgloss.JoinVertical(lipgloss.Left, muted.Bold(true).Render("▾ "+header), "", body),
+		WithBorderColor(t.TextMuted()),
+		WithFullWidth(),
+	)
+}
+
+// formatTokenCount shortens a token count to thousands, e.g. 1.2K
+func formatTokenCount(tokens float32) string {
+	if tokens < 1_000 {
+		return fmt.Sprintf("%d", int(tokens))
+	}
+	return strings.Replace(fmt.Sprintf("%.1fK", float64(tokens)/1_000), ".0K", "K", 1)
+}
+
+// renderFiles renders the file parts of a message as chips with the file name
+// and media type, followed by previews of the images when there is room
+func renderFiles(message client.MessageInfo, files []client.MessagePartFile) string {
+	t := theme.CurrentTheme()
+	maxWidth := layout.Current.Container.Width - 6 // -6 for the border and padding
+	chip := styles.BaseStyle().Background(t.BackgroundElement())
+
+	var rows []string
+	row := ""
+	for _, file := range files {
+		name := "file"
+		if file.Filename != nil && *file.Filename != "" {
+			name = *file.Filename
+		} else if !strings.HasPrefix(file.Url, "data:") {
+			name = filepath.Base(file.Url)
+		}
+		name = ansi.Truncate(name, max(1, maxWidth-lipgloss.Width(file.MediaType)-6), "...")
+		if !strings.HasPrefix(file.Url, "data:") {
+			name = ansi.SetHyperlink(file.Url) + name + ansi.ResetHyperlink()
+		}
+		rendered := chip.Padding(0, 1).Render(
+			chip.Foreground(t.Text()).Render(styles.DocumentIcon+" "+name) +
+				chip.Foreground(t.TextMuted()).Render(" "+file.MediaType),
+		)
+		if row != "" && lipgloss.Width(row)+1+lipgloss.Width(rendered) > maxWidth {
+			rows = append(rows, row)
+			row = ""
+		}
+		if row != "" {
+			row += " "
+		}
+		row += rendered
+	}
+	rows = append(rows, row)
+
+	for _, file := range files {
+		if preview := renderImagePreview(file, maxWidth); preview != "" {
+			rows = append(rows, "", preview)
+		}
+	}
+
+	align := lipgloss.Left
+	borderColor := t.Accent()
+	if message.Role == client.User {
+		align = lipgloss.Right
+		borderColor = t.Secondary()
+	}
+	return renderContentBlock(
+		lipgloss.JoinVertical(align, rows...),
+		WithAlign(align),
+		WithBorderColor(borderColor),
+	)
+}
+
+// renderImagePreview renders an image file part with half block characters,
+// or nothing if it isn't an image or there isn't enough room
+func renderImagePreview(file client.MessagePartFile, maxWidth int) string {
+	if !strings.HasPrefix(file.MediaType, "image/") || layout.Current.Viewport.Height < 20 {
+		return ""
+	}
+	img, err := image.DecodeDataURL(file.Url)
+	if err != nil {
+		slog.Debug("Failed to decode image", "error", err)
+		return ""
+	}
+
+	// keep the preview within a third of the screen, each line is two
+	// pixels high
+	bounds := img.Bounds()
+	width := min(40, maxWidth)
+	maxLines := layout.Current.Viewport.Height / 3
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return ""
+	}
+	if lines := width * bounds.Dy() / bounds.Dx() / 2; lines > maxLines {
+		width = maxLines * 2 * bounds.Dx() / bounds.Dy()
+	}
+	if width < 8 {
+		return ""
+	}
+	return strings.TrimSuffix(image.ToString(width, img), "\n")
+}
+
+// renderSources renders the source URLs cited by a message as a numbered
+// list of links
+func renderSources(sources []client.MessagePartSourceUrl) string {
+	t := theme.CurrentTheme()
+	maxWidth := layout.Current.Container.Width - 6 // -6 for the border and padding
+	base := styles.BaseStyle().Background(t.BackgroundSubtle())
+
+	lines := []string{base.Foreground(t.TextMuted()).Bold(true).Render("Sources")}
+	for i, source := range sources {
+		number := fmt.Sprintf("%d. ", i+1)
+		host := ""
+		if u, err := url.Parse(source.Url); err == nil && u.Host != "" {
+			host = " " + strings.TrimPrefix(u.Host, "www.")
+		}
+		title := source.Url
+		if source.Title != nil && *source.Title != "" {
+			title = *source.Title
+		} else {
+			host = ""
+		}
+		title = ansi.Truncate(title, max(1, maxWidth-len(number)-lipgloss.Width(host)), "...")
+		lines = append(lines,
+			base.Foreground(t.TextMuted()).Render(number)+
+				base.Foreground(t.Primary()).Render(ansi.SetHyperlink(source.Url)+title+ansi.ResetHyperlink())+
+				base.Foreground(t.TextMuted()).Render(host),
+		)
+	}
+
+	return renderContentBlock(
+		strings.Join(lines, "\n"),
+		WithBorderColor(t.TextMuted()),
+	)
+}
+
+// renderQueuedPrompt renders a prompt that is waiting to be sent as a muted
+// user message
+func renderQueuedPrompt(prompt app.QueuedPrompt) string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width
+	padding := 0
+	if layout.Current.Viewport.Width < 80 {
+		padding = 5
+	} else if layout.Current.Viewport.Width < 120 {
+		padding = 15
+	} else {
+		padding = 20
+	}
+
+	info := "queued"
+	if len(prompt.Attachments) > 0 {
+		info = fmt.Sprintf("queued, %d attachment(s)", len(prompt.Attachments))
+	}
+	textWidth := max(lipgloss.Width(prompt.Text), lipgloss.Width(info))
+	text := styles.BaseStyle().
+		Background(t.BackgroundSubtle()).
+		Foreground(t.TextMuted()).
+		Width(min(textWidth, width-padding-4)).
+		Render(prompt.Text)
+	content := lipgloss.JoinVertical(lipgloss.Right, text, info)
+
+	return renderContentBlock(content,
+		WithAlign(lipgloss.Right),
+		WithBorderColor(t.TextMuted()),
+	)
+}
+
+// toolView is how a tool invocation block is drawn
+type toolView struct {
+	showResult bool
+	// expanded shows the whole result instead of its first lines
+	expanded bool
+	// selected highlights the block under the block cursor
+	selected bool
+}
+
 func renderToolInvocation(
 	toolCall client.MessageToolInvocationToolCall,
 	result *string,
 	metadata client.MessageInfo_Metadata_Tool_AdditionalProperties,
-	showResult bool,
+	schema map[string]any,
+	view toolView,
 ) string {
-	ignoredTools := []string{"opencode_todoread"}
-	if slices.Contains(ignoredTools, toolCall.ToolName) {
+	if slices.Contains(hiddenTools, toolCall.ToolName) {
 		return ""
 	}
 
 	outerWidth := layout.Current.Container.Width
-	innerWidth := outerWidth - 6
+	showResult := view.showResult
 	paddingTop := 0
 	paddingBottom := 0
 	if showResult {
@@ -272,43 +476,50 @@ func renderToolInvocation(
 		BorderRight(true).
 		BorderForeground(t.BackgroundSubtle()).
 		BorderStyle(lipgloss.ThickBorder())
-
-	if toolCall.State == "partial-call" {
-		style = style.Foreground(t.TextMuted())
-		return style.Render(renderToolAction(toolCall.ToolName))
+	if view.selected {
+		style = style.BorderForeground(t.Primary())
 	}
 
-	toolArgs := ""
-	toolArgsMap := make(map[string]any)
+	call := ToolCall{
+		ID:       toolCall.ToolCallId,
+		Name:     toolCall.ToolName,
+		Args:     map[string]any{},
+		Metadata: metadata,
+		Schema:   schema,
+		Expanded: view.expanded,
+	}
 	if toolCall.Args != nil {
-		value := *toolCall.Args
-		m, ok := value.(map[string]any)
-		if ok {
-			toolArgsMap = m
-			firstKey := ""
-			for key := range toolArgsMap {
-				firstKey = key
-				break
-			}
-			toolArgs = renderArgs(&toolArgsMap, firstKey)
+		if args, ok := (*toolCall.Args).(map[string]any); ok {
+			call.Args = args
 		}
 	}
+	if result != nil {
+		call.Result = *result
+	}
+	renderer := getToolRenderer(toolCall.ToolName)
+
+	if toolCall.State == "partial-call" {
+		style = style.Foreground(t.TextMuted())
+		return style.Render(renderer.Action(call))
+	}
 
-	if len(toolArgsMap) == 0 {
+	if len(call.Args) == 0 {
 		slog.Debug("no args")
 	}
 
 	body := ""
 	error := ""
-	finished := result != nil && *result != ""
 
-	if e, ok := metadata.Get("error"); ok && e.(bool) == true {
-		if m, ok := metadata.Get("message"); ok {
-			style = style.BorderLeftForeground(t.Error())
+	failed, _ := metadata.Get("error")
+	if failed == true {
+		if m, _ := metadata.Get("message"); m != nil {
+			if !view.selected {
+				style = style.BorderLeftForeground(t.Error())
+			}
 			error = styles.BaseStyle().
 				Background(t.BackgroundSubtle()).
 				Foreground(t.Error()).
-				Render(m.(string))
+				Render(fmt.Sprint(m))
 			error = renderContentBlock(
 				error,
 				WithFullWidth(),
@@ -329,117 +540,34 @@ func renderToolInvocation(
 	}
 	elapsed = styles.Muted().Render(roundedDuration.String())
 
-	title := ""
-	switch toolCall.ToolName {
-	case "opencode_read":
-		toolArgs = renderArgs(&toolArgsMap, "filePath")
-		title = fmt.Sprintf("Read: %s   %s", toolArgs, elapsed)
-		if preview, ok := metadata.Get("preview"); ok && toolArgsMap["filePath"] != nil {
-			filename := toolArgsMap["filePath"].(string)
-			body = preview.(string)
-			body = renderFile(filename, body, WithTruncate(6))
-		}
-	case "opencode_edit":
-		if filename, ok := toolArgsMap["filePath"].(string); ok {
-			title = fmt.Sprintf("Edit: %s   %s", relative(filename), elapsed)
-			if d, ok := metadata.Get("diff"); ok {
-				patch := d.(string)
-				var formattedDiff string
-				if layout.Current.Viewport.Width < 80 {
-					formattedDiff, _ = diff.FormatUnifiedDiff(
-						filename,
-						patch,
-						diff.WithWidth(layout.Current.Container.Width-2),
-					)
-				} else {
-					diffWidth := min(layout.Current.Viewport.Width-2, 120)
-					formattedDiff, _ = diff.FormatDiff(filename, patch, diff.WithTotalWidth(diffWidth))
-				}
-				formattedDiff = strings.TrimSpace(formattedDiff)
-				formattedDiff = lipgloss.NewStyle().
-					BorderStyle(lipgloss.ThickBorder()).
-					BorderForeground(t.BackgroundSubtle()).
-					BorderLeft(true).
-					BorderRight(true).
-					Render(formattedDiff)
-
-				if showResult {
-					style = style.Width(lipgloss.Width(formattedDiff))
-					title += "\n"
-				}
-
-				body = strings.TrimSpace(formattedDiff)
-				body = lipgloss.Place(
-					layout.Current.Viewport.Width,
-					lipgloss.Height(body)+1,
-					lipgloss.Center,
-					lipgloss.Top,
-					body,
-					lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Background(t.Background())),
-				)
-			}
-		}
-	case "opencode_write":
-		if filename, ok := toolArgsMap["filePath"].(string); ok {
-			title = fmt.Sprintf("Write: %s   %s", relative(filename), elapsed)
-			if content, ok := toolArgsMap["content"].(string); ok {
-				body = renderFile(filename, content)
-			}
-		}
-	case "opencode_bash":
-		if description, ok := toolArgsMap["description"].(string); ok {
-			title = fmt.Sprintf("Shell: %s   %s", description, elapsed)
-		}
-		if stdout, ok := metadata.Get("stdout"); ok {
-			command := toolArgsMap["command"].(string)
-			stdout := stdout.(string)
-			body = fmt.Sprintf("```console\n> %s\n%s```", command, stdout)
-			body = toMarkdown(body, innerWidth, t.BackgroundSubtle())
-			body = renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
-		}
-	case "opencode_webfetch":
-		toolArgs = renderArgs(&toolArgsMap, "url")
-		title = fmt.Sprintf("Fetching: %s   %s", toolArgs, elapsed)
-		if format, ok := toolArgsMap["format"].(string); ok {
-			body = *result
-			body = truncateHeight(body, 10)
-			if format == "html" || format == "markdown" {
-				body = toMarkdown(body, innerWidth, t.BackgroundSubtle())
-			}
-			body = renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+	title := renderer.Title(call)
+	if !showResult && call.Finished() {
+		if summary := renderer.Summary(call); summary != "" {
+			title = fmt.Sprintf("%s   %s", title, summary)
 		}
-	case "opencode_todowrite":
-		title = fmt.Sprintf("Planning   %s", elapsed)
-
-		if to, ok := metadata.Get("todos"); ok && finished {
-			todos := to.([]any)
-			for _, todo := range todos {
-				t := todo.(map[string]any)
-				content := t["content"].(string)
-				switch t["status"].(string) {
-				case "completed":
-					body += fmt.Sprintf("- [x] %s\n", content)
-				// case "in-progress":
-				// 	body += fmt.Sprintf("- [ ] %s\n", content)
-				default:
-					body += fmt.Sprintf("- [ ] %s\n", content)
-				}
-			}
-			body = toMarkdown(body, innerWidth, t.BackgroundSubtle())
+	}
+	title = fmt.Sprintf("%s   %s", title, elapsed)
+
+	if showResult {
+		body = renderer.Body(call)
+		if body == "" && error == "" {
+			body = call.Truncate(call.Result, 10)
 			body = renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
 		}
-	default:
-		toolName := renderToolName(toolCall.ToolName)
-		title = fmt.Sprintf("%s: %s   %s", toolName, toolArgs, elapsed)
-		body = *result
-		body = truncateHeight(body, 10)
-		body = renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
 	}
 
-	if body == "" && error == "" {
-		body = *result
-		body = truncateHeight(body, 10)
-		body = renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+	if wide, ok := renderer.(FullWidthToolRenderer); ok && wide.FullWidth() && body != "" {
+		// the title spans the body, which is centered on the screen
+		style = style.Width(lipgloss.Width(body))
+		title += "\n"
+		body = lipgloss.Place(
+			layout.Current.Viewport.Width,
+			lipgloss.Height(body)+1,
+			lipgloss.Center,
+			lipgloss.Top,
+			body,
+			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Background(t.Background())),
+		)
 	}
 
 	content := style.Render(title)
@@ -458,27 +586,6 @@ func renderToolInvocation(
 	return content
 }
 
-func renderToolName(name string) string {
-	switch name {
-	// case agent.AgentToolName:
-	// 	return "Task"
-	case "opencode_ls":
-		return "List"
-	case "opencode_webfetch":
-		return "Fetch"
-	case "opencode_todoread":
-		return "Planning"
-	case "opencode_todowrite":
-		return "Planning"
-	default:
-		normalizedName := name
-		if strings.HasPrefix(name, "opencode_") {
-			normalizedName = strings.TrimPrefix(name, "opencode_")
-		}
-		return cases.Title(language.Und).String(normalizedName)
-	}
-}
-
 type fileRenderer struct {
 	filename string
 	content  string
@@ -521,49 +628,28 @@ func renderFile(filename string, content string, options ...fileRenderingOption)
 	return renderContentBlock(content, WithFullWidth(), WithMarginBottom(1))
 }
 
-func renderToolAction(name string) string {
-	switch name {
-	// case agent.AgentToolName:
-	// 	return "Preparing prompt..."
-	case "opencode_bash":
-		return "Building command..."
-	case "opencode_edit":
-		return "Preparing edit..."
-	case "opencode_fetch":
-		return "Writing fetch..."
-	case "opencode_glob":
-		return "Finding files..."
-	case "opencode_grep":
-		return "Searching content..."
-	case "opencode_ls":
-		return "Listing directory..."
-	case "opencode_read":
-		return "Reading file..."
-	case "opencode_write":
-		return "Preparing write..."
-	case "opencode_patch":
-		return "Preparing patch..."
-	case "opencode_batch":
-		return "Running batch operations..."
-	}
-	return "Working..."
-}
-
 func renderArgs(args *map[string]any, titleKey string) string {
 	if args == nil || len(*args) == 0 {
 		return ""
 	}
+	return renderArgList(*args, slices.Sorted(maps.Keys(*args)), titleKey)
+}
+
+// renderArgList renders the arguments named by keys, in that order, with the
+// titleKey argument first
+func renderArgList(args map[string]any, keys []string, titleKey string) string {
 	title := ""
 	parts := []string{}
-	for key, value := range *args {
+	for _, key := range keys {
+		value := args[key]
 		if value == nil {
 			continue
 		}
-		if key == "filePath" || key == "path" {
-			value = relative(value.(string))
+		if path, ok := value.(string); ok && (key == "filePath" || key == "path") {
+			value = relative(path)
 		}
 		if key == titleKey {
-			title = fmt.Sprintf("%s", value)
+			title = fmt.Sprintf("%v", value)
 			continue
 		}
 		parts = append(parts, fmt.Sprintf("%s=%v", key, value))
@@ -1,6 +1,8 @@
 package chat
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -23,15 +25,48 @@ type messagesComponent struct {
 	width, height   int
 	viewport        viewport.Model
 	spinner         spinner.Model
-	rendering       bool
 	attachments     viewport.Model
 	showToolResults bool
 	cache           *MessageCache
 	tail            bool
+	// thinking holds the reasoning blocks expanded or collapsed by hand, by
+	// message ID and part index, overriding the show_thinking setting
+	thinking map[string]bool
+	// thinkingLines are the first lines of the rendered reasoning blocks
+	thinkingLines []blockLine
+	// toolLines are the first lines of the rendered tool blocks, by tool
+	// call ID
+	toolLines []blockLine
+	// tools holds the tool blocks expanded or collapsed by hand, by tool
+	// call ID, and selected is the tool call under the block cursor
+	tools    map[string]toolDisplay
+	selected string
+	// rendered is the render tree, the messages by ID
+	rendered map[string]*renderedMessage
+	// offset is the transcript line at the top of the screen, contentStart
+	// the transcript line the viewport content starts at
+	offset       int
+	contentStart int
+	// total is the number of lines in the transcript, laid out as items
+	total int
+	items []transcriptItem
+	// resizeID identifies the last resize, renders of earlier resizes are
+	// dropped
+	resizeID int
 }
-type renderFinishedMsg struct{}
+
+// blockLine is the first line of a block, key identifies the block
+type blockLine struct {
+	key  string
+	line int
+}
+
 type ToggleToolMessagesMsg struct{}
 
+// ToggleThinkingMsg expands or collapses the last reasoning block that starts
+// on screen
+type ToggleThinkingMsg struct{}
+
 type MessageKeys struct {
 	PageDown     key.Binding
 	PageUp       key.Binding
@@ -66,26 +101,48 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	switch msg := msg.(type) {
 	case SendMsg:
-		m.viewport.GotoBottom()
 		m.tail = true
+		m.selectTool("")
+		return m, nil
+	case SelectToolMsg:
+		m.moveSelection(msg.Delta)
+		return m, nil
+	case ExpandToolMsg:
+		m.expandTool(msg.All)
+		return m, nil
+	case CollapseToolMsg:
+		m.collapseTool()
 		return m, nil
 	case dialog.ThemeChangedMsg:
-		m.cache.Clear()
 		m.renderView()
 		return m, nil
 	case ToggleToolMessagesMsg:
 		m.showToolResults = !m.showToolResults
 		m.renderView()
 		return m, nil
+	case ToggleThinkingMsg:
+		m.toggleThinking()
+		return m, nil
+	case state.ShowThinkingChangedMsg:
+		clear(m.thinking)
+		m.renderView()
+		return m, nil
 	case state.SessionSelectedMsg:
-		m.cache.Clear()
+		clear(m.rendered)
+		m.selected = ""
+		m.tail = true
 		cmd := m.Reload()
-		m.viewport.GotoBottom()
 		return m, cmd
 	case state.SessionClearedMsg:
-		m.cache.Clear()
+		clear(m.rendered)
+		m.selected = ""
 		cmd := m.Reload()
 		return m, cmd
+	case resizedMsg:
+		if msg.id == m.resizeID {
+			m.renderView()
+		}
+		return m, nil
 	case tea.KeyMsg:
 		if key.Matches(msg, messageKeys.PageUp) ||
 			key.Matches(msg, messageKeys.PageDown) ||
@@ -93,19 +150,17 @@ func (m *messagesComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			key.Matches(msg, messageKeys.HalfPageDown) {
 			u, cmd := m.viewport.Update(msg)
 			m.viewport = u
-			m.tail = m.viewport.AtBottom()
+			m.scrolled()
 			cmds = append(cmds, cmd)
 		}
-	case renderFinishedMsg:
-		m.rendering = false
-		if m.tail {
-			m.viewport.GotoBottom()
+	case state.QueueUpdatedMsg:
+		if msg.SessionID == m.app.Session.Id {
+			m.updateView()
 		}
+	case state.MessageUpdatedMsg:
+		m.renderMessage(msg.MessageID)
 	case state.StateUpdatedMsg:
 		m.renderView()
-		if m.tail {
-			m.viewport.GotoBottom()
-		}
 	}
 
 	spinner, cmd := m.spinner.Update(msg)
@@ -121,118 +176,209 @@ const (
 	userTextBlock
 	assistantTextBlock
 	toolInvocationBlock
+	reasoningBlock
+	fileBlock
+	sourcesBlock
 	errorBlock
 )
 
-func (m *messagesComponent) renderView() {
-	if m.width == 0 {
+// thinkingExpanded reports whether a reasoning block is shown in full
+func (m *messagesComponent) thinkingExpanded(key string) bool {
+	if expanded, ok := m.thinking[key]; ok {
+		return expanded
+	}
+	return m.app.Config.ShowThinking
+}
+
+func (m *messagesComponent) toggleThinking() {
+	bottom := m.offset + m.viewport.Height()
+	for i := len(m.thinkingLines) - 1; i >= 0; i-- {
+		block := m.thinkingLines[i]
+		if block.line >= bottom {
+			continue
+		}
+		if m.thinking == nil {
+			m.thinking = map[string]bool{}
+		}
+		m.thinking[block.key] = !m.thinkingExpanded(block.key)
+		m.renderView()
 		return
 	}
+}
 
+type renderedBlock struct {
+	kind    blockType
+	content string
+	// thinkingKey is set for reasoning blocks
+	thinkingKey string
+	// toolCallID is set for tool invocation blocks
+	toolCallID string
+}
+
+// renderBlocks renders the parts of a message into centered blocks
+func (m *messagesComponent) renderBlocks(message client.MessageInfo) renderedMessage {
 	t := theme.CurrentTheme()
-	blocks := make([]string, 0)
-	previousBlockType := none
-	for _, message := range m.app.Messages {
-		var content string
-		var cached bool
-
-		author := ""
-		switch message.Role {
-		case client.User:
-			author = app.Info.User
-		case client.Assistant:
-			author = message.Metadata.Assistant.ModelID
+	blocks := []renderedBlock{}
+	var content string
+	var cached bool
+	// the reasoning token count goes in the first block of the message
+	reasoningTokens := float32(0)
+	if message.Metadata.Assistant != nil {
+		reasoningTokens = message.Metadata.Assistant.Tokens.Reasoning
+	}
+	// files and sources are shown together after the other parts
+	var files []client.MessagePartFile
+	var sources []client.MessagePartSourceUrl
+
+	author := ""
+	switch message.Role {
+	case client.User:
+		author = app.Info.User
+	case client.Assistant:
+		author = message.Metadata.Assistant.ModelID
+	}
+
+	for i, p := range message.Parts {
+		part, err := p.ValueByDiscriminator()
+		if err != nil {
+			continue //TODO: handle error?
 		}
 
-		for _, p := range message.Parts {
-			part, err := p.ValueByDiscriminator()
-			if err != nil {
-				continue //TODO: handle error?
+		switch part.(type) {
+		// case client.MessagePartStepStart:
+		// 	messages = append(messages, "")
+		case client.MessagePartText:
+			text := part.(client.MessagePartText)
+			key := m.cache.GenerateKey(message.Id, text.Text, layout.Current.Viewport.Width)
+			content, cached = m.cache.Get(key)
+			if !cached {
+				content = renderText(message, text.Text, author)
+				m.cache.Set(key, content)
+			}
+			if message.Role == client.User {
+				blocks = append(blocks, renderedBlock{kind: userTextBlock, content: content})
+			} else if message.Role == client.Assistant {
+				blocks = append(blocks, renderedBlock{kind: assistantTextBlock, content: content})
+			}
+		case client.MessagePartFile:
+			files = append(files, part.(client.MessagePartFile))
+		case client.MessagePartSourceUrl:
+			source := part.(client.MessagePartSourceUrl)
+			if !slices.ContainsFunc(sources, func(s client.MessagePartSourceUrl) bool { return s.Url == source.Url }) {
+				sources = append(sources, source)
+			}
+		case client.MessagePartReasoning:
+			reasoning := part.(client.MessagePartReasoning)
+			if strings.TrimSpace(reasoning.Text) == "" {
+				continue
+			}
+			thinkingKey := fmt.Sprintf("%s:%d", message.Id, i)
+			expanded := m.thinkingExpanded(thinkingKey)
+			streaming := message.Metadata.Time.Completed == nil && i == len(message.Parts)-1
+			key := m.cache.GenerateKey(message.Id, reasoning.Text, reasoningTokens, expanded, streaming, layout.Current.Viewport.Width)
+			content, cached = m.cache.Get(key)
+			if !cached {
+				content = renderReasoning(reasoning.Text, reasoningTokens, expanded, streaming)
+				m.cache.Set(key, content)
+			}
+			reasoningTokens = 0
+			blocks = append(blocks, renderedBlock{kind: reasoningBlock, content: content, thinkingKey: thinkingKey})
+		case client.MessagePartToolInvocation:
+			toolInvocationPart := part.(client.MessagePartToolInvocation)
+			toolCall, _ := toolInvocationPart.ToolInvocation.AsMessageToolInvocationToolCall()
+			metadata := client.MessageInfo_Metadata_Tool_AdditionalProperties{}
+			if _, ok := message.Metadata.Tool[toolCall.ToolCallId]; ok {
+				metadata = message.Metadata.Tool[toolCall.ToolCallId]
+			}
+			var result *string
+			resultPart, resultError := toolInvocationPart.ToolInvocation.AsMessageToolInvocationToolResult()
+			if resultError == nil {
+				result = &resultPart.Result
 			}
 
-			switch part.(type) {
-			// case client.MessagePartStepStart:
-			// 	messages = append(messages, "")
-			case client.MessagePartText:
-				text := part.(client.MessagePartText)
-				key := m.cache.GenerateKey(message.Id, text.Text, layout.Current.Viewport.Width)
+			view := m.toolView(toolCall.ToolCallId)
+			if toolCall.State == "result" {
+				key := m.cache.GenerateKey(message.Id,
+					toolCall.ToolCallId,
+					view.showResult,
+					view.expanded,
+					view.selected,
+					layout.Current.Viewport.Width,
+				)
 				content, cached = m.cache.Get(key)
 				if !cached {
-					content = renderText(message, text.Text, author)
+					content = renderToolInvocation(toolCall, result, metadata, m.app.ToolSchema(toolCall.ToolName), view)
 					m.cache.Set(key, content)
 				}
-				if previousBlockType != none {
-					blocks = append(blocks, "")
-				}
-				blocks = append(blocks, content)
-				if message.Role == client.User {
-					previousBlockType = userTextBlock
-				} else if message.Role == client.Assistant {
-					previousBlockType = assistantTextBlock
-				}
-			case client.MessagePartToolInvocation:
-				toolInvocationPart := part.(client.MessagePartToolInvocation)
-				toolCall, _ := toolInvocationPart.ToolInvocation.AsMessageToolInvocationToolCall()
-				metadata := client.MessageInfo_Metadata_Tool_AdditionalProperties{}
-				if _, ok := message.Metadata.Tool[toolCall.ToolCallId]; ok {
-					metadata = message.Metadata.Tool[toolCall.ToolCallId]
-				}
-				var result *string
-				resultPart, resultError := toolInvocationPart.ToolInvocation.AsMessageToolInvocationToolResult()
-				if resultError == nil {
-					result = &resultPart.Result
-				}
-
-				if toolCall.State == "result" {
-					key := m.cache.GenerateKey(message.Id,
-						toolCall.ToolCallId,
-						m.showToolResults,
-						layout.Current.Viewport.Width,
-					)
-					content, cached = m.cache.Get(key)
-					if !cached {
-						content = renderToolInvocation(toolCall, result, metadata, m.showToolResults)
-						m.cache.Set(key, content)
-					}
-				} else {
-					// if the tool call isn't finished, never cache
-					content = renderToolInvocation(toolCall, result, metadata, m.showToolResults)
-				}
-
-				if previousBlockType != toolInvocationBlock {
-					blocks = append(blocks, "")
-				}
-				blocks = append(blocks, content)
-				previousBlockType = toolInvocationBlock
+			} else {
+				// if the tool call isn't finished, never cache
+				content = renderToolInvocation(toolCall, result, metadata, m.app.ToolSchema(toolCall.ToolName), view)
+			}
+			block := renderedBlock{kind: toolInvocationBlock, content: content}
+			// hidden tools can't be selected
+			if content != "" {
+				block.toolCallID = toolCall.ToolCallId
 			}
+			blocks = append(blocks, block)
 		}
+	}
 
-		error := ""
-		if message.Metadata.Error != nil {
-			errorValue, _ := message.Metadata.Error.ValueByDiscriminator()
-			switch errorValue.(type) {
-			case client.UnknownError:
-				clientError := errorValue.(client.UnknownError)
-				error = clientError.Data.Message
-				error = renderContentBlock(error, WithBorderColor(t.Error()), WithFullWidth(), WithMarginTop(1), WithMarginBottom(1))
-				blocks = append(blocks, error)
-				previousBlockType = errorBlock
-			}
+	if len(files) > 0 {
+		key := m.cache.GenerateKey(message.Id, "files", len(files), layout.Current.Viewport.Width, layout.Current.Viewport.Height)
+		content, cached = m.cache.Get(key)
+		if !cached {
+			content = renderFiles(message, files)
+			m.cache.Set(key, content)
 		}
+		blocks = append(blocks, renderedBlock{kind: fileBlock, content: content})
 	}
 
-	centered := []string{}
-	for _, block := range blocks {
-		centered = append(centered, lipgloss.PlaceHorizontal(
-			m.width,
-			lipgloss.Center,
-			block,
-			lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Background(t.Background())),
-		))
+	if len(sources) > 0 {
+		blocks = append(blocks, renderedBlock{kind: sourcesBlock, content: renderSources(sources)})
+	}
+
+	error := ""
+	if message.Metadata.Error != nil {
+		errorValue, _ := message.Metadata.Error.ValueByDiscriminator()
+		switch errorValue.(type) {
+		case client.UnknownError:
+			clientError := errorValue.(client.UnknownError)
+			error = clientError.Data.Message
+		case client.ProviderAuthError:
+			clientError := errorValue.(client.ProviderAuthError)
+			error = fmt.Sprintf("Authentication with %s failed: %s", clientError.Data.ProviderID, clientError.Data.Message)
+		}
+		if error != "" {
+			error = renderContentBlock(error, WithBorderColor(t.Error()), WithFullWidth(), WithMarginTop(1), WithMarginBottom(1))
+			blocks = append(blocks, renderedBlock{kind: errorBlock, content: error})
+		}
 	}
 
-	m.viewport.SetHeight(m.height - lipgloss.Height(m.header()))
-	m.viewport.SetContent("\n" + strings.Join(centered, "\n") + "\n")
+	rendered := renderedMessage{rendered: true}
+	if len(blocks) == 0 {
+		return rendered
+	}
+	rendered.first = blocks[0].kind
+	rendered.last = blocks[len(blocks)-1].kind
+
+	whitespace := lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Background(t.Background()))
+	centered := []string{}
+	for i, block := range blocks {
+		if i > 0 && separated(blocks[i-1].kind, block.kind) {
+			centered = append(centered, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "", whitespace))
+			rendered.height++
+		}
+		if block.thinkingKey != "" {
+			rendered.thinking = append(rendered.thinking, blockLine{key: block.thinkingKey, line: rendered.height})
+		}
+		if block.toolCallID != "" {
+			rendered.tools = append(rendered.tools, blockLine{key: block.toolCallID, line: rendered.height})
+		}
+		rendered.height += lipgloss.Height(block.content)
+		centered = append(centered, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, block.content, whitespace))
+	}
+	rendered.content = strings.Join(centered, "\n")
+	return rendered
 }
 
 func (m *messagesComponent) header() string {
@@ -272,9 +418,6 @@ func (m *messagesComponent) View() string {
 	if len(m.app.Messages) == 0 {
 		return m.home()
 	}
-	if m.rendering {
-		return m.viewport.View()
-	}
 	t := theme.CurrentTheme()
 	return lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -346,11 +489,7 @@ func (m *messagesComponent) home() string {
 	// lines = append(lines, "")
 	lines = append(lines, commandLines...)
 	lines = append(lines, "")
-	if m.rendering {
-		lines = append(lines, styles.Muted().Render("Loading session..."))
-	} else {
-		lines = append(lines, "")
-	}
+	lines = append(lines, "")
 
 	t := theme.CurrentTheme()
 	return lipgloss.Place(
@@ -372,16 +511,19 @@ func (m *messagesComponent) SetSize(width, height int) tea.Cmd {
 	if m.width == width && m.height == height {
 		return nil
 	}
-	// Clear cache on resize since width affects rendering
-	if m.width != width {
-		m.cache.Clear()
-	}
+	// wait for the terminal to stop resizing before rendering for a new
+	// width, the old lines are kept until then
+	resized := m.width != 0 && m.width != width
 	m.width = width
 	m.height = height
 	m.viewport.SetWidth(width)
 	m.viewport.SetHeight(height - lipgloss.Height(m.header()))
 	m.attachments.SetWidth(width + 40)
 	m.attachments.SetHeight(3)
+	if resized {
+		m.updateView()
+		return m.resize()
+	}
 	m.renderView()
 	return nil
 }
@@ -390,12 +532,11 @@ func (m *messagesComponent) GetSize() (int, int) {
 	return m.width, m.height
 }
 
+// Reload renders the transcript again. It only renders the messages on and
+// near the screen, so it runs in Update, which owns the render tree.
 func (m *messagesComponent) Reload() tea.Cmd {
-	m.rendering = true
-	return func() tea.Msg {
-		m.renderView()
-		return renderFinishedMsg{}
-	}
+	m.renderView()
+	return nil
 }
 
 func NewMessagesComponent(app *app.App) layout.ModelWithView {
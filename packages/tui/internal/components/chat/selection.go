@@ -0,0 +1,251 @@
+package chat
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/sst/opencode/internal/status"
+)
+
+// hiddenTools are tool invocations that aren't shown in the transcript
+var hiddenTools = []string{"opencode_todoread"}
+
+// toolDisplay is how much of a tool invocation block is shown
+type toolDisplay int
+
+const (
+	// toolDefault shows the first lines of the result, or just the title
+	// while tool results are toggled off
+	toolDefault toolDisplay = iota
+	// toolCollapsed shows just the title
+	toolCollapsed
+	// toolExpanded shows the whole result
+	toolExpanded
+)
+
+// SelectToolMsg moves the block cursor to the previous (-1) or next (1) tool
+// invocation. Moving past the last one clears the selection.
+type SelectToolMsg struct {
+	Delta int
+}
+
+// ExpandToolMsg toggles the whole result of the selected tool invocation, or
+// of every invocation of the same tool if All is set
+type ExpandToolMsg struct {
+	All bool
+}
+
+// CollapseToolMsg toggles the selected tool invocation between its title and
+// its usual size
+type CollapseToolMsg struct{}
+
+type toolBlock struct {
+	id      string
+	name    string
+	message int
+}
+
+// toolBlocks returns the tool invocations of the session in order
+func (m *messagesComponent) toolBlocks() []toolBlock {
+	var blocks []toolBlock
+	for i, message := range m.app.Messages {
+		for _, part := range message.Parts {
+			invocation, err := part.AsMessagePartToolInvocation()
+			if err != nil || invocation.Type != "tool-invocation" {
+				continue
+			}
+			toolCall, err := invocation.ToolInvocation.AsMessageToolInvocationToolCall()
+			if err != nil || slices.Contains(hiddenTools, toolCall.ToolName) {
+				continue
+			}
+			blocks = append(blocks, toolBlock{id: toolCall.ToolCallId, name: toolCall.ToolName, message: i})
+		}
+	}
+	return blocks
+}
+
+func (m *messagesComponent) toolView(id string) toolView {
+	display := m.tools[id]
+	return toolView{
+		showResult: display == toolExpanded || display == toolDefault && m.showToolResults,
+		expanded:   display == toolExpanded,
+		selected:   id != "" && id == m.selected,
+	}
+}
+
+func (m *messagesComponent) moveSelection(delta int) {
+	blocks := m.toolBlocks()
+	if len(blocks) == 0 {
+		return
+	}
+
+	idx := slices.IndexFunc(blocks, func(b toolBlock) bool { return b.id == m.selected })
+	if idx < 0 {
+		// start from the blocks on screen
+		idx = len(blocks) - 1
+		if id, ok := m.toolOnScreen(delta); ok {
+			idx = max(slices.IndexFunc(blocks, func(b toolBlock) bool { return b.id == id }), 0)
+		}
+		status.Info("alt+enter to expand, alt+x to collapse, alt+e to expand every " + getToolRenderer(blocks[idx].name).Name() + " block")
+	} else {
+		idx += delta
+	}
+
+	if idx >= len(blocks) {
+		// back to following the transcript
+		m.tail = true
+		m.selectTool("")
+		return
+	}
+	m.selectTool(blocks[max(idx, 0)].id)
+}
+
+// toolOnScreen returns the last tool block starting on screen when moving
+// up, the first one when moving down
+func (m *messagesComponent) toolOnScreen(delta int) (string, bool) {
+	top, bottom := m.offset, m.offset+m.viewport.Height()
+	lines := slices.Clone(m.toolLines)
+	if delta < 0 {
+		slices.Reverse(lines)
+	}
+	for _, tool := range lines {
+		if tool.line >= top && tool.line < bottom {
+			return tool.key, true
+		}
+	}
+	return "", false
+}
+
+// selectTool moves the block cursor to a tool call, or clears it if id is
+// empty, and scrolls the block into view
+func (m *messagesComponent) selectTool(id string) {
+	previous := m.selected
+	m.selected = id
+	m.invalidateTool(previous)
+	m.invalidateTool(id)
+	if id == "" {
+		m.updateView()
+		return
+	}
+	m.scrollToTool(id)
+}
+
+// invalidateTool marks the message with a tool call to be rendered again
+func (m *messagesComponent) invalidateTool(id string) {
+	if id == "" {
+		return
+	}
+	for _, block := range m.toolBlocks() {
+		if block.id == id {
+			if node, ok := m.rendered[m.app.Messages[block.message].Id]; ok {
+				node.rendered = false
+				node.content = ""
+			}
+			return
+		}
+	}
+}
+
+// scrollToTool scrolls the transcript so a tool block starts on screen
+func (m *messagesComponent) scrollToTool(id string) {
+	m.updateView()
+	line, ok := m.toolLine(id)
+	if !ok {
+		// the block is away from the rendered lines, go to its message first
+		for _, block := range m.toolBlocks() {
+			if block.id == id && block.message < len(m.items) {
+				m.offset = m.items[block.message].top
+				m.tail = false
+				m.updateView()
+			}
+		}
+		if line, ok = m.toolLine(id); !ok {
+			return
+		}
+	}
+
+	height := m.viewport.Height()
+	if line >= m.offset && line < m.offset+height {
+		return
+	}
+	if line < m.offset {
+		m.offset = line
+	} else {
+		// keep the block in the middle of the screen when moving down
+		m.offset = line - height/2
+	}
+	m.tail = m.offset >= m.total-height
+	m.updateView()
+}
+
+func (m *messagesComponent) toolLine(id string) (int, bool) {
+	for _, tool := range m.toolLines {
+		if tool.key == id {
+			return tool.line, true
+		}
+	}
+	return 0, false
+}
+
+// expandTool toggles the whole result of the selected tool block, or of all
+// the blocks of the same tool
+func (m *messagesComponent) expandTool(all bool) {
+	if m.selected == "" {
+		return
+	}
+	if m.tools == nil {
+		m.tools = map[string]toolDisplay{}
+	}
+	if !all {
+		m.toggleTool(m.selected, toolExpanded)
+		m.invalidateTool(m.selected)
+		m.scrollToTool(m.selected)
+		return
+	}
+
+	blocks := m.toolBlocks()
+	idx := slices.IndexFunc(blocks, func(b toolBlock) bool { return b.id == m.selected })
+	if idx < 0 {
+		return
+	}
+	name := blocks[idx].name
+	blocks = slices.DeleteFunc(blocks, func(b toolBlock) bool { return b.name != name })
+	expanded := !slices.ContainsFunc(blocks, func(b toolBlock) bool { return m.tools[b.id] != toolExpanded })
+	for _, block := range blocks {
+		if expanded {
+			delete(m.tools, block.id)
+		} else {
+			m.tools[block.id] = toolExpanded
+		}
+	}
+	if expanded {
+		status.Info(fmt.Sprintf("Restored %d %s blocks", len(blocks), getToolRenderer(name).Name()))
+	} else {
+		status.Info(fmt.Sprintf("Expanded %d %s blocks", len(blocks), getToolRenderer(name).Name()))
+	}
+	m.renderView()
+	m.scrollToTool(m.selected)
+}
+
+// collapseTool toggles the selected tool block between its title and its
+// usual size
+func (m *messagesComponent) collapseTool() {
+	if m.selected == "" {
+		return
+	}
+	if m.tools == nil {
+		m.tools = map[string]toolDisplay{}
+	}
+	m.toggleTool(m.selected, toolCollapsed)
+	m.invalidateTool(m.selected)
+	m.scrollToTool(m.selected)
+}
+
+// toggleTool switches a tool block between a display and the default
+func (m *messagesComponent) toggleTool(id string, display toolDisplay) {
+	if m.tools[id] == display {
+		delete(m.tools, id)
+	} else {
+		m.tools[id] = display
+	}
+}
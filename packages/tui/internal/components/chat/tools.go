@@ -0,0 +1,430 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/pkg/client"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// ToolCall is a tool invocation as handed to a ToolRenderer
+type ToolCall struct {
+	ID   string
+	Name string
+	Args map[string]any
+	// Result is empty until the tool has finished
+	Result   string
+	Metadata client.MessageInfo_Metadata_Tool_AdditionalProperties
+	// Schema is the JSON schema of the tool's input, nil if the server didn't
+	// list the tool
+	Schema map[string]any
+	// Expanded is set when the whole result should be shown rather than its
+	// first lines
+	Expanded bool
+}
+
+// Finished reports whether the tool has returned a result
+func (c ToolCall) Finished() bool {
+	return c.Result != ""
+}
+
+// Truncate limits content to height lines unless the block is expanded
+func (c ToolCall) Truncate(content string, height int) string {
+	if c.Expanded {
+		return content
+	}
+	return truncateHeight(content, height)
+}
+
+// ToolRenderer draws the invocations of a tool in the transcript
+type ToolRenderer interface {
+	// Name is the short name of the tool, as used in status messages
+	Name() string
+	// Action is shown while the model is still writing the arguments
+	Action(call ToolCall) string
+	// Title is the first line of the block, the elapsed time is added after it
+	Title(call ToolCall) string
+	// Body renders what is shown below the title, or "" to show the result
+	// as plain text
+	Body(call ToolCall) string
+	// Summary describes the result in a few words, it is shown after the
+	// title while the body is hidden
+	Summary(call ToolCall) string
+}
+
+// FullWidthToolRenderer is implemented by renderers whose body is laid out
+// across the screen rather than in the message column, like side by side
+// diffs. The title is widened to match the body.
+type FullWidthToolRenderer interface {
+	ToolRenderer
+	FullWidth() bool
+}
+
+var (
+	toolRenderers   = map[string]ToolRenderer{}
+	toolRenderersMu sync.RWMutex
+)
+
+// RegisterToolRenderer sets the renderer for the invocations of a tool,
+// replacing the one already registered for it. Tools without a renderer are
+// drawn by a renderer that lays out their arguments and result by JSON type.
+func RegisterToolRenderer(name string, renderer ToolRenderer) {
+	toolRenderersMu.Lock()
+	defer toolRenderersMu.Unlock()
+
+	toolRenderers[name] = renderer
+}
+
+// getToolRenderer returns the renderer for a tool
+func getToolRenderer(name string) ToolRenderer {
+	toolRenderersMu.RLock()
+	defer toolRenderersMu.RUnlock()
+
+	if renderer, ok := toolRenderers[name]; ok {
+		return renderer
+	}
+	return jsonToolRenderer{toolLabel: toolLabel{name: renderToolName(name), action: "Working..."}}
+}
+
+func init() {
+	RegisterToolRenderer("opencode_read", readToolRenderer{toolLabel{"Read", "Reading file..."}})
+	RegisterToolRenderer("opencode_edit", editToolRenderer{toolLabel{"Edit", "Preparing edit..."}})
+	RegisterToolRenderer("opencode_write", writeToolRenderer{toolLabel{"Write", "Preparing write..."}})
+	RegisterToolRenderer("opencode_bash", bashToolRenderer{toolLabel{"Shell", "Building command..."}})
+	RegisterToolRenderer("opencode_webfetch", fetchToolRenderer{toolLabel{"Fetch", "Writing fetch..."}})
+	RegisterToolRenderer("opencode_todowrite", todoToolRenderer{toolLabel{"Planning", "Planning..."}})
+	RegisterToolRenderer("opencode_todoread", todoToolRenderer{toolLabel{"Planning", "Planning..."}})
+	RegisterToolRenderer("opencode_glob", jsonToolRenderer{toolLabel{"Glob", "Finding files..."}, "pattern"})
+	RegisterToolRenderer("opencode_grep", jsonToolRenderer{toolLabel{"Grep", "Searching content..."}, "pattern"})
+	RegisterToolRenderer("opencode_ls", jsonToolRenderer{toolLabel{"List", "Listing directory..."}, "path"})
+	RegisterToolRenderer("opencode_patch", jsonToolRenderer{toolLabel{"Patch", "Preparing patch..."}, ""})
+	RegisterToolRenderer("opencode_batch", jsonToolRenderer{toolLabel{"Batch", "Running batch operations..."}, ""})
+}
+
+// renderToolName turns the name of a tool without a renderer into a title
+func renderToolName(name string) string {
+	return cases.Title(language.Und).String(strings.TrimPrefix(name, "opencode_"))
+}
+
+// toolLabel is the name and in-progress label of a tool
+type toolLabel struct {
+	name   string
+	action string
+}
+
+func (l toolLabel) Name() string {
+	return l.name
+}
+
+func (l toolLabel) Action(ToolCall) string {
+	return l.action
+}
+
+// countLines describes the number of lines in content
+func countLines(content string) string {
+	content = strings.TrimRight(content, "\n")
+	if content == "" {
+		return ""
+	}
+	lines := strings.Count(content, "\n") + 1
+	if lines == 1 {
+		return "1 line"
+	}
+	return fmt.Sprintf("%d lines", lines)
+}
+
+type readToolRenderer struct {
+	toolLabel
+}
+
+func (r readToolRenderer) Title(call ToolCall) string {
+	return "Read: " + renderArgs(&call.Args, "filePath")
+}
+
+func (r readToolRenderer) Body(call ToolCall) string {
+	filename, ok := call.Args["filePath"].(string)
+	if !ok {
+		return ""
+	}
+	if call.Expanded && call.Finished() {
+		return renderFile(filename, readContent(call.Result))
+	}
+	preview, ok := call.Metadata.Get("preview")
+	if !ok {
+		return ""
+	}
+	content, ok := preview.(string)
+	if !ok {
+		return jsonToolRenderer{}.Body(call)
+	}
+	return renderFile(filename, content, WithTruncate(6))
+}
+
+func (r readToolRenderer) Summary(call ToolCall) string {
+	return countLines(readContent(call.Result))
+}
+
+// readContent unwraps the lines of a file from a read result, dropping the
+// <file> tags, the note about lines left to read and the line numbers
+func readContent(result string) string {
+	content := strings.TrimPrefix(result, "<file>\n")
+	if i := strings.LastIndex(content, "\n\n(File has more lines."); i >= 0 {
+		content = content[:i]
+	}
+	content = strings.TrimSuffix(content, "\n</file>")
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		if number, rest, ok := strings.Cut(line, "| "); ok && isDigits(number) {
+			lines[i] = rest
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+type editToolRenderer struct {
+	toolLabel
+}
+
+func (r editToolRenderer) FullWidth() bool {
+	return true
+}
+
+func (r editToolRenderer) Title(call ToolCall) string {
+	filename, _ := call.Args["filePath"].(string)
+	return "Edit: " + relative(filename)
+}
+
+func (r editToolRenderer) Body(call ToolCall) string {
+	t := theme.CurrentTheme()
+	filename, ok := call.Args["filePath"].(string)
+	d, hasDiff := call.Metadata.Get("diff")
+	if !ok || !hasDiff {
+		return ""
+	}
+	patch, ok := d.(string)
+	if !ok {
+		return jsonToolRenderer{}.Body(call)
+	}
+
+	var formattedDiff string
+	if layout.Current.Viewport.Width < 80 {
+		formattedDiff, _ = diff.FormatUnifiedDiff(
+			filename,
+			patch,
+			diff.WithWidth(layout.Current.Container.Width-2),
+		)
+	} else {
+		diffWidth := min(layout.Current.Viewport.Width-2, 120)
+		formattedDiff, _ = diff.FormatDiff(filename, patch, diff.WithTotalWidth(diffWidth))
+	}
+	formattedDiff = strings.TrimSpace(formattedDiff)
+	return lipgloss.NewStyle().
+		BorderStyle(lipgloss.ThickBorder()).
+		BorderForeground(t.BackgroundSubtle()).
+		BorderLeft(true).
+		BorderRight(true).
+		Render(formattedDiff)
+}
+
+func (r editToolRenderer) Summary(call ToolCall) string {
+	d, ok := call.Metadata.Get("diff")
+	if !ok {
+		return ""
+	}
+	patch, ok := d.(string)
+	if !ok {
+		return jsonToolRenderer{}.Summary(call)
+	}
+	added, removed := 0, 0
+	for line := range strings.SplitSeq(patch, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return fmt.Sprintf("+%d -%d", added, removed)
+}
+
+type writeToolRenderer struct {
+	toolLabel
+}
+
+func (r writeToolRenderer) Title(call ToolCall) string {
+	filename, _ := call.Args["filePath"].(string)
+	return "Write: " + relative(filename)
+}
+
+func (r writeToolRenderer) Body(call ToolCall) string {
+	filename, ok := call.Args["filePath"].(string)
+	content, hasContent := call.Args["content"].(string)
+	if !ok || !hasContent {
+		return ""
+	}
+	return renderFile(filename, content)
+}
+
+func (r writeToolRenderer) Summary(call ToolCall) string {
+	content, _ := call.Args["content"].(string)
+	return countLines(content)
+}
+
+type bashToolRenderer struct {
+	toolLabel
+}
+
+func (r bashToolRenderer) Title(call ToolCall) string {
+	description, _ := call.Args["description"].(string)
+	return "Shell: " + description
+}
+
+func (r bashToolRenderer) Body(call ToolCall) string {
+	t := theme.CurrentTheme()
+	stdout, ok := call.Metadata.Get("stdout")
+	if !ok {
+		return ""
+	}
+	output, ok := stdout.(string)
+	if !ok {
+		return jsonToolRenderer{}.Body(call)
+	}
+	command, _ := call.Args["command"].(string)
+	body := fmt.Sprintf("```console\n> %s\n%s```", command, output)
+	body = toMarkdown(body, layout.Current.Container.Width-6, t.BackgroundSubtle())
+	return renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+}
+
+func (r bashToolRenderer) Summary(call ToolCall) string {
+	stdout, _ := call.Metadata.Get("stdout")
+	output, _ := stdout.(string)
+	return countLines(output)
+}
+
+type fetchToolRenderer struct {
+	toolLabel
+}
+
+func (r fetchToolRenderer) Title(call ToolCall) string {
+	return "Fetching: " + renderArgs(&call.Args, "url")
+}
+
+func (r fetchToolRenderer) Body(call ToolCall) string {
+	t := theme.CurrentTheme()
+	format, ok := call.Args["format"].(string)
+	if !ok {
+		return ""
+	}
+	body := call.Truncate(call.Result, 10)
+	if format == "html" || format == "markdown" {
+		body = toMarkdown(body, layout.Current.Container.Width-6, t.BackgroundSubtle())
+	}
+	return renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+}
+
+func (r fetchToolRenderer) Summary(call ToolCall) string {
+	return countLines(call.Result)
+}
+
+type todoToolRenderer struct {
+	toolLabel
+}
+
+func (r todoToolRenderer) Title(call ToolCall) string {
+	return "Planning"
+}
+
+// todo is an item of the todo list in the metadata of the todo tools
+type todo struct {
+	content string
+	status  string
+}
+
+// todos returns the todo list of a finished call. ok is false when the
+// metadata doesn't hold a todo list.
+func (r todoToolRenderer) todos(call ToolCall) (todos []todo, ok bool) {
+	to, found := call.Metadata.Get("todos")
+	if !found || !call.Finished() {
+		return nil, true
+	}
+	items, ok := to.([]any)
+	if !ok {
+		return nil, false
+	}
+	todos = []todo{}
+	for _, item := range items {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		content, ok := fields["content"].(string)
+		if !ok {
+			return nil, false
+		}
+		status, _ := fields["status"].(string)
+		todos = append(todos, todo{content: content, status: status})
+	}
+	return todos, true
+}
+
+func (r todoToolRenderer) Body(call ToolCall) string {
+	t := theme.CurrentTheme()
+	todos, ok := r.todos(call)
+	if !ok {
+		return jsonToolRenderer{}.Body(call)
+	}
+	if todos == nil {
+		return ""
+	}
+
+	body := ""
+	for _, todo := range todos {
+		switch todo.status {
+		case "completed":
+			body += fmt.Sprintf("- [x] %s\n", todo.content)
+		// case "in-progress":
+		// 	body += fmt.Sprintf("- [ ] %s\n", todo.content)
+		default:
+			body += fmt.Sprintf("- [ ] %s\n", todo.content)
+		}
+	}
+	body = toMarkdown(body, layout.Current.Container.Width-6, t.BackgroundSubtle())
+	return renderContentBlock(body, WithFullWidth(), WithMarginBottom(1))
+}
+
+func (r todoToolRenderer) Summary(call ToolCall) string {
+	todos, ok := r.todos(call)
+	if !ok {
+		return jsonToolRenderer{}.Summary(call)
+	}
+	if len(todos) == 0 {
+		return ""
+	}
+	completed := 0
+	for _, todo := range todos {
+		if todo.status == "completed" {
+			completed++
+		}
+	}
+	return fmt.Sprintf("%d/%d done", completed, len(todos))
+}
@@ -0,0 +1,86 @@
+package chat
+
+import "testing"
+
+func TestReadContent(t *testing.T) {
+	tests := []struct {
+		name   string
+		result string
+		want   string
+	}{
+		{
+			name:   "whole file",
+			result: "<file>\n00001| package main\n00002| \n00003| func main() {}\n</file>",
+			want:   "package main\n\nfunc main() {}",
+		},
+		{
+			name:   "file with more lines",
+			result: "<file>\n00001| one\n00002| two\n\n(File has more lines. Use 'offset' parameter to read beyond line 2)\n</file>",
+			want:   "one\ntwo",
+		},
+		{
+			name:   "read from an offset",
+			result: "<file>\n00120| one\n00121| two\n</file>",
+			want:   "one\ntwo",
+		},
+		{
+			name:   "line numbers past five digits",
+			result: "<file>\n123456| one\n</file>",
+			want:   "one",
+		},
+		{
+			name:   "pipe inside a line is kept",
+			result: "<file>\n00001| a | b\n00002| x| y\n</file>",
+			want:   "a | b\nx| y",
+		},
+		{
+			name:   "line without a number is kept",
+			result: "<file>\nnot numbered| text\n</file>",
+			want:   "not numbered| text",
+		},
+		{
+			name:   "note text inside the file",
+			result: "<file>\n00001| (File has more lines. but not the note)\n</file>",
+			want:   "(File has more lines. but not the note)",
+		},
+		{
+			name:   "not a read result",
+			result: "File not found: main.go",
+			want:   "File not found: main.go",
+		},
+		{
+			name:   "empty",
+			result: "",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := readContent(tt.result); got != tt.want {
+				t.Errorf("readContent(%q) = %q, want %q", tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountLines(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "", want: ""},
+		{content: "\n\n", want: ""},
+		{content: "one", want: "1 line"},
+		{content: "one\n", want: "1 line"},
+		{content: "one\n\nthree", want: "3 lines"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			if got := countLines(tt.content); got != tt.want {
+				t.Errorf("countLines(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
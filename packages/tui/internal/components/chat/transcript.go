@@ -0,0 +1,274 @@
+package chat
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/pkg/client"
+)
+
+// resizeDelay is how long the transcript waits for the terminal to stop
+// resizing before rendering again
+const resizeDelay = 100 * time.Millisecond
+
+type resizedMsg struct {
+	id int
+}
+
+// renderedMessage is a message in the render tree. Its height is kept once
+// it has been rendered, so the lines of the transcript stay put, but its
+// content only while the message is on or near the screen.
+type renderedMessage struct {
+	content string
+	// rendered is set while content is up to date with the message
+	rendered bool
+	height   int
+	// first and last are the types of the first and last blocks, first is
+	// none if the message has no blocks
+	first, last blockType
+	// thinking and tools hold the lines of the reasoning and tool blocks
+	// within content
+	thinking []blockLine
+	tools    []blockLine
+}
+
+// transcriptItem is a message or queued prompt laid out in the transcript
+type transcriptItem struct {
+	// top is the first line of the item, after the separator if it has one
+	top       int
+	separator bool
+	height    int
+	// message is the index of the message, node its place in the render
+	// tree. Queued prompts have no node, just content.
+	message int
+	node    *renderedMessage
+	content string
+}
+
+func (i transcriptItem) overlaps(start, end int) bool {
+	top := i.top
+	if i.separator {
+		top--
+	}
+	return top < end && i.top+max(i.height, 1) > start
+}
+
+// renderView renders every message again, as it comes into view
+func (m *messagesComponent) renderView() {
+	for _, node := range m.rendered {
+		node.rendered = false
+		node.content = ""
+	}
+	m.updateView()
+}
+
+// renderMessage renders a single message again, reusing the other messages
+// as they were rendered last
+func (m *messagesComponent) renderMessage(id string) {
+	if node, ok := m.rendered[id]; ok {
+		node.rendered = false
+		node.content = ""
+	}
+	m.updateView()
+}
+
+// resize renders the transcript for a new width once the terminal has
+// stopped resizing
+func (m *messagesComponent) resize() tea.Cmd {
+	m.resizeID++
+	id := m.resizeID
+	return tea.Tick(resizeDelay, func(time.Time) tea.Msg {
+		return resizedMsg{id: id}
+	})
+}
+
+// separated reports whether an empty line goes between two blocks
+func separated(previous, next blockType) bool {
+	switch next {
+	case errorBlock:
+		return false
+	case toolInvocationBlock:
+		return previous != toolInvocationBlock
+	default:
+		return previous != none
+	}
+}
+
+// estimateHeight guesses the height of a message that hasn't been rendered
+// yet, it is replaced by the real height once the message comes into view
+func estimateHeight(message client.MessageInfo) int {
+	width := max(layout.Current.Container.Width-6, 10)
+	height := 0
+	for _, p := range message.Parts {
+		part, err := p.ValueByDiscriminator()
+		if err != nil {
+			continue
+		}
+		switch part := part.(type) {
+		case client.MessagePartText:
+			for line := range strings.SplitSeq(part.Text, "\n") {
+				height += max(1, (ansi.StringWidth(line)+width-1)/width)
+			}
+			height += 4
+		case client.MessagePartReasoning, client.MessagePartToolInvocation, client.MessagePartFile:
+			height += 4
+		}
+	}
+	return height
+}
+
+// layoutItems places the messages and queued prompts in the transcript.
+// Messages that haven't been rendered get an estimated height.
+func (m *messagesComponent) layoutItems(queued []string) []transcriptItem {
+	items := make([]transcriptItem, 0, len(m.app.Messages)+len(queued))
+	// the content starts with an empty line
+	line := 1
+	previous := none
+	for i, message := range m.app.Messages {
+		node, ok := m.rendered[message.Id]
+		if !ok {
+			node = &renderedMessage{height: estimateHeight(message)}
+			if node.height > 0 {
+				node.first, node.last = assistantTextBlock, assistantTextBlock
+			}
+			m.rendered[message.Id] = node
+		}
+		item := transcriptItem{message: i, node: node, height: node.height}
+		if node.first != none {
+			item.separator = separated(previous, node.first)
+			previous = node.last
+		}
+		if item.separator {
+			line++
+		}
+		item.top = line
+		line += item.height
+		items = append(items, item)
+	}
+	for _, prompt := range queued {
+		line++
+		height := lipgloss.Height(prompt)
+		items = append(items, transcriptItem{top: line, separator: true, height: height, content: prompt})
+		line += height
+	}
+	// and ends with one
+	m.total = line + 1
+	return items
+}
+
+// updateView renders the messages on screen and a screen above and below,
+// and hands just those lines to the viewport. The content of messages further
+// away is dropped.
+func (m *messagesComponent) updateView() {
+	if m.width == 0 {
+		return
+	}
+
+	t := theme.CurrentTheme()
+	whitespace := lipgloss.WithWhitespaceStyle(lipgloss.NewStyle().Background(t.Background()))
+	separator := lipgloss.PlaceHorizontal(m.width, lipgloss.Center, "", whitespace)
+	height := max(m.height-lipgloss.Height(m.header()), 1)
+	m.viewport.SetHeight(height)
+
+	if m.rendered == nil {
+		m.rendered = map[string]*renderedMessage{}
+	}
+	var queued []string
+	for _, prompt := range m.app.Queued(m.app.Session.Id) {
+		queued = append(queued, lipgloss.PlaceHorizontal(m.width, lipgloss.Center, renderQueuedPrompt(prompt), whitespace))
+	}
+
+	// rendering a message settles its height, which moves the messages
+	// after it, so lay out again until everything in the window is rendered
+	var items []transcriptItem
+	var start, end int
+	for {
+		items = m.layoutItems(queued)
+		if m.tail {
+			m.offset = m.total - height
+		}
+		m.offset = max(0, min(m.offset, m.total-height))
+		start, end = m.offset-height, m.offset+2*height
+
+		shift := 0
+		rendered := false
+		for _, item := range items {
+			if item.node == nil || item.node.rendered || !item.overlaps(start, end) {
+				continue
+			}
+			rendered = true
+			before := item.node.height
+			*item.node = m.renderBlocks(m.app.Messages[item.message])
+			// keep the lines on screen in place when a message above them
+			// changes height
+			if !m.tail && item.top+shift < m.offset {
+				m.offset += item.node.height - before
+			}
+			shift += item.node.height - before
+		}
+		if !rendered {
+			break
+		}
+	}
+
+	m.items = items
+	m.thinkingLines = m.thinkingLines[:0]
+	m.toolLines = m.toolLines[:0]
+	lines := []string{}
+	m.contentStart = -1
+	if start <= 0 {
+		lines = append(lines, "")
+		m.contentStart = 0
+	}
+	for _, item := range items {
+		if !item.overlaps(start, end) {
+			if item.node != nil && item.node.rendered {
+				item.node.rendered = false
+				item.node.content = ""
+			}
+			continue
+		}
+		if item.height == 0 {
+			continue
+		}
+		if m.contentStart < 0 {
+			m.contentStart = item.top
+			if item.separator {
+				m.contentStart--
+			}
+		}
+		if item.separator {
+			lines = append(lines, separator)
+		}
+		if item.node == nil {
+			lines = append(lines, item.content)
+			continue
+		}
+		for _, thinking := range item.node.thinking {
+			m.thinkingLines = append(m.thinkingLines, blockLine{key: thinking.key, line: item.top + thinking.line})
+		}
+		for _, tool := range item.node.tools {
+			m.toolLines = append(m.toolLines, blockLine{key: tool.key, line: item.top + tool.line})
+		}
+		lines = append(lines, item.node.content)
+	}
+	if end >= m.total {
+		lines = append(lines, "")
+	}
+	m.contentStart = max(m.contentStart, 0)
+
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.viewport.SetYOffset(m.offset - m.contentStart)
+}
+
+// scrolled moves the window after the viewport has been scrolled
+func (m *messagesComponent) scrolled() {
+	m.offset = m.contentStart + m.viewport.YOffset
+	m.tail = m.offset >= m.total-m.viewport.Height()
+	m.updateView()
+}
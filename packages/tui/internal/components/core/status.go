@@ -7,12 +7,14 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/sst/opencode/internal/app"
 	"github.com/sst/opencode/internal/layout"
 	"github.com/sst/opencode/internal/pubsub"
 	"github.com/sst/opencode/internal/status"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/pkg/client"
 )
 
 type StatusComponent interface {
@@ -20,11 +22,12 @@ type StatusComponent interface {
 }
 
 type statusComponent struct {
-	app         *app.App
-	queue       []status.StatusMessage
-	width       int
-	messageTTL  time.Duration
-	activeUntil time.Time
+	app          *app.App
+	queue        []status.StatusMessage
+	width        int
+	messageTTL   time.Duration
+	activeUntil  time.Time
+	disconnected bool
 }
 
 // clearMessageCmd is a command that clears status messages after a timeout
@@ -48,6 +51,12 @@ func (m statusComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		return m, nil
+	case client.EventDisconnected:
+		m.disconnected = true
+		return m, nil
+	case client.EventReconnected:
+		m.disconnected = false
+		return m, nil
 	case pubsub.Event[status.StatusMessage]:
 		if msg.Type == status.EventStatusPublished {
 			// If this is a critical message, move it to the front of the queue
@@ -139,12 +148,79 @@ func formatTokensAndCost(tokens float32, contextWindow float32, cost float32) st
 	return fmt.Sprintf("Tokens: %s (%d%%), Cost: %s", formattedTokens, int(percentage), formattedCost)
 }
 
+// banner renders the line above the status bar. A lost connection to the
+// server takes it over, otherwise it shows the active status message.
+func (m statusComponent) banner() string {
+	t := theme.CurrentTheme()
+	if m.disconnected {
+		return styles.Padded().
+			Background(t.Error()).
+			Foreground(t.Background()).
+			Width(m.width).
+			Render("disconnected from server, reconnecting...")
+	}
+	if message := m.statusMessage(); message != "" {
+		return message
+	}
+	return styles.BaseStyle().Background(t.Background()).Width(m.width).Render("")
+}
+
+// statusMessage renders the active message published through the status
+// service, or "" if there is none
+func (m statusComponent) statusMessage() string {
+	if len(m.queue) == 0 || m.activeUntil.IsZero() {
+		return ""
+	}
+
+	t := theme.CurrentTheme()
+	sm := m.queue[0]
+	infoStyle := styles.Padded().
+		Foreground(t.Background()).
+		Width(m.width)
+
+	switch sm.Level {
+	case status.LevelInfo:
+		infoStyle = infoStyle.Background(t.Info())
+	case status.LevelWarn:
+		infoStyle = infoStyle.Background(t.Warning())
+	case status.LevelError:
+		infoStyle = infoStyle.Background(t.Error())
+	case status.LevelDebug:
+		infoStyle = infoStyle.Background(t.TextMuted())
+	}
+
+	msg := strings.ReplaceAll(sm.Message, "\n", " ")
+	msg = ansi.Truncate(msg, max(0, m.width-2), "...")
+	return infoStyle.Render(msg)
+}
+
+// projectDiagnostics renders the number of errors and warnings reported by
+// the language servers, or nothing when there are none
+func (m statusComponent) projectDiagnostics() string {
+	errors, warnings := m.app.DiagnosticCounts()
+	if errors == 0 && warnings == 0 {
+		return ""
+	}
+
+	t := theme.CurrentTheme()
+	base := lipgloss.NewStyle().Background(t.BackgroundElement())
+	badge := ""
+	if errors > 0 {
+		badge += base.Foreground(t.Error()).Render(fmt.Sprintf("%s %d", styles.ErrorIcon, errors))
+	}
+	if warnings > 0 {
+		if badge != "" {
+			badge += base.Render(" ")
+		}
+		badge += base.Foreground(t.Warning()).Render(fmt.Sprintf("%s %d", styles.WarningIcon, warnings))
+	}
+	return styles.Padded().Background(t.BackgroundElement()).Render(badge)
+}
+
 func (m statusComponent) View() string {
 	if m.app.Session.Id == "" {
-		return styles.BaseStyle().
-			Width(m.width).
-			Height(2).
-			Render("")
+		blank := styles.BaseStyle().Width(m.width).Render("")
+		return m.banner() + "\n" + blank
 	}
 
 	t := theme.CurrentTheme()
@@ -177,18 +253,17 @@ func (m statusComponent) View() string {
 			Render(formatTokensAndCost(tokens, contextWindow, cost))
 	}
 
-	// diagnostics := styles.Padded().Background(t.BackgroundElement()).Render(m.projectDiagnostics())
+	diagnostics := m.projectDiagnostics()
 
 	space := max(
 		0,
-		m.width-lipgloss.Width(logo)-lipgloss.Width(cwd)-lipgloss.Width(sessionInfo),
+		m.width-lipgloss.Width(logo)-lipgloss.Width(cwd)-lipgloss.Width(diagnostics)-lipgloss.Width(sessionInfo),
 	)
 	spacer := lipgloss.NewStyle().Background(t.BackgroundSubtle()).Width(space).Render("")
 
-	status := logo + cwd + spacer + sessionInfo
+	status := logo + cwd + spacer + diagnostics + sessionInfo
 
-	blank := styles.BaseStyle().Background(t.Background()).Width(m.width).Render("")
-	return blank + "\n" + status
+	return m.banner() + "\n" + status
 
 	// Display the first status message if available
 	// var statusMessage string
@@ -0,0 +1,197 @@
+package dialog
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+	"github.com/sst/opencode/pkg/client"
+)
+
+// maxDiagnosticLines is the number of diagnostics shown for the selected file
+const maxDiagnosticLines = 8
+
+// FixDiagnosticsMsg is sent to ask the agent to fix the diagnostics in Prompt
+type FixDiagnosticsMsg struct {
+	Prompt string
+}
+
+// DiagnosticsDialog interface for the project diagnostics dialog
+type DiagnosticsDialog interface {
+	layout.Modal
+}
+
+type diagnosticFileItem struct {
+	file app.DiagnosticFile
+}
+
+func (d diagnosticFileItem) Render(selected bool, width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().Background(t.BackgroundElement())
+	errorStyle := baseStyle.Foreground(t.Error())
+	warningStyle := baseStyle.Foreground(t.Warning())
+	pathStyle := baseStyle.Foreground(t.Text())
+
+	if selected {
+		baseStyle = baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement()).Bold(true)
+		errorStyle, warningStyle, pathStyle = baseStyle, baseStyle, baseStyle
+	}
+
+	counts := ""
+	if d.file.Errors > 0 {
+		counts += errorStyle.Render(fmt.Sprintf(" %s %d", styles.ErrorIcon, d.file.Errors))
+	}
+	if d.file.Warnings > 0 {
+		counts += warningStyle.Render(fmt.Sprintf(" %s %d", styles.WarningIcon, d.file.Warnings))
+	}
+
+	// width - 2 for the item, less the padding and the counts
+	pathWidth := max(0, width-4-lipgloss.Width(counts))
+	path := pathStyle.Width(pathWidth).Render(ansi.Truncate(d.file.Path, pathWidth, "..."))
+	return baseStyle.Padding(0, 1).Render(path + counts)
+}
+
+type diagnosticsDialog struct {
+	width  int
+	height int
+
+	app   *app.App
+	modal *modal.Modal
+	list  list.List[diagnosticFileItem]
+}
+
+func (d *diagnosticsDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (d *diagnosticsDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		d.width = msg.Width
+		d.height = msg.Height
+		d.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case client.EventLspClientDiagnostics:
+		// keep the selected file selected as the list changes
+		selected, _ := d.list.GetSelectedItem()
+		d.list.SetItems(diagnosticFileItems(d.app))
+		for i, item := range d.list.GetItems() {
+			if item.file.Path == selected.file.Path {
+				d.list.SetSelectedIndex(i)
+			}
+		}
+		return d, nil
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "f":
+			if files := d.app.DiagnosticFiles(); len(files) > 0 {
+				return d, d.fix(files)
+			}
+			return d, nil
+		case "enter":
+			if item, idx := d.list.GetSelectedItem(); idx >= 0 {
+				return d, d.fix([]app.DiagnosticFile{item.file})
+			}
+			return d, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	listModel, cmd := d.list.Update(msg)
+	d.list = listModel.(list.List[diagnosticFileItem])
+	return d, cmd
+}
+
+func (d *diagnosticsDialog) fix(files []app.DiagnosticFile) tea.Cmd {
+	return tea.Sequence(
+		util.CmdHandler(modal.CloseModalMsg{}),
+		util.CmdHandler(FixDiagnosticsMsg{Prompt: app.DiagnosticsPrompt(files)}),
+	)
+}
+
+func (d *diagnosticsDialog) View() string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 14
+	base := styles.BaseStyle().Background(t.BackgroundElement())
+	muted := base.Foreground(t.TextMuted())
+	bold := base.Foreground(t.Text()).Bold(true)
+
+	if d.list.IsEmpty() {
+		return muted.Width(width).Render("No errors or warnings reported by the language servers")
+	}
+
+	lines := []string{d.list.View(), base.Width(width).Render("")}
+	item, _ := d.list.GetSelectedItem()
+	for i, diagnostic := range item.file.Diagnostics {
+		if i == maxDiagnosticLines {
+			lines = append(lines, muted.Width(width).Render(fmt.Sprintf("... and %d more", len(item.file.Diagnostics)-i)))
+			break
+		}
+		icon := base.Foreground(t.Error()).Render(styles.ErrorIcon)
+		if diagnostic.Severity == client.LspDiagnosticSeverityWarning {
+			icon = base.Foreground(t.Warning()).Render(styles.WarningIcon)
+		}
+		position := fmt.Sprintf(" %d:%d ", int(diagnostic.Line), int(diagnostic.Character))
+		message := ansi.Truncate(diagnostic.Message, max(0, width-lipgloss.Width(icon+position)), "...")
+		lines = append(lines, base.Width(width).Render(
+			icon+muted.Render(position)+base.Foreground(t.Text()).Render(message),
+		))
+	}
+
+	lines = append(lines,
+		base.Width(width).Render(""),
+		base.Width(width).Render(
+			bold.Render("enter")+muted.Render(" fix file  ")+
+				bold.Render("f")+muted.Render(" fix all  ")+
+				bold.Render("esc")+muted.Render(" close"),
+		),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (d *diagnosticsDialog) Render(background string) string {
+	return d.modal.Render(d.View(), background)
+}
+
+func (d *diagnosticsDialog) Close() tea.Cmd {
+	return nil
+}
+
+// IsDiagnosticsDialog reports whether the modal is the diagnostics dialog
+func IsDiagnosticsDialog(m layout.Modal) bool {
+	_, ok := m.(*diagnosticsDialog)
+	return ok
+}
+
+func diagnosticFileItems(app *app.App) []diagnosticFileItem {
+	var items []diagnosticFileItem
+	for _, file := range app.DiagnosticFiles() {
+		items = append(items, diagnosticFileItem{file: file})
+	}
+	return items
+}
+
+// NewDiagnosticsDialog creates a dialog listing the files with errors and
+// warnings from the language servers, which can be sent to the agent to fix
+func NewDiagnosticsDialog(app *app.App) DiagnosticsDialog {
+	list := list.NewListComponent(
+		diagnosticFileItems(app),
+		6, // maxVisibleItems
+		"",
+		false, // useAlphaNumericKeys
+	)
+	list.SetMaxWidth(layout.Current.Container.Width - 12)
+
+	return &diagnosticsDialog{
+		app:   app,
+		list:  list,
+		modal: modal.New(modal.WithTitle("Diagnostics"), modal.WithMaxWidth(80)),
+	}
+}
@@ -2,15 +2,21 @@ package dialog
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/v2/key"
 	"github.com/charmbracelet/bubbles/v2/viewport"
 	tea "github.com/charmbracelet/bubbletea/v2"
 	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/diff"
+	"github.com/sst/opencode/internal/components/modal"
 	"github.com/sst/opencode/internal/layout"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
-	"strings"
+	"github.com/sst/opencode/pkg/client"
 )
 
 type PermissionAction string
@@ -22,16 +28,27 @@ const (
 	PermissionDeny            PermissionAction = "deny"
 )
 
+// Response returns the server's name for the action.
+func (a PermissionAction) Response() client.PostPermissionRespondJSONBodyResponse {
+	switch a {
+	case PermissionAllow:
+		return client.Once
+	case PermissionAllowForSession:
+		return client.Always
+	}
+	return client.Reject
+}
+
 // PermissionResponseMsg represents the user's response to a permission request
 type PermissionResponseMsg struct {
-	// Permission permission.PermissionRequest
-	Action PermissionAction
+	Permission client.PermissionInfo
+	Action     PermissionAction
 }
 
-// PermissionDialogComponent interface for permission dialog component
-type PermissionDialogComponent interface {
-	layout.ModelWithView
-	// SetPermissions(permission permission.PermissionRequest) tea.Cmd
+// PermissionDialog interface for the permission request dialog
+type PermissionDialog interface {
+	layout.Modal
+	Permission() client.PermissionInfo
 }
 
 type permissionsMapping struct {
@@ -75,60 +92,62 @@ var permissionsKeys = permissionsMapping{
 	),
 }
 
-// permissionDialogComponent is the implementation of PermissionDialog
-type permissionDialogComponent struct {
+// permissionDialog shows the oldest pending permission request of the app
+type permissionDialog struct {
 	width  int
 	height int
-	// permission      permission.PermissionRequest
-	windowSize      tea.WindowSizeMsg
+
+	app             *app.App
+	permission      client.PermissionInfo
+	modal           *modal.Modal
 	contentViewPort viewport.Model
 	selectedOption  int // 0: Allow, 1: Allow for session, 2: Deny
 
-	diffCache     map[string]string
-	markdownCache map[string]string
+	// content is the rendered metadata, for contentWidth
+	content      string
+	contentWidth int
 }
 
-func (p *permissionDialogComponent) Init() tea.Cmd {
-	return p.contentViewPort.Init()
+func (p *permissionDialog) Init() tea.Cmd {
+	return nil
 }
 
-func (p *permissionDialogComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
-	var cmds []tea.Cmd
-
+func (p *permissionDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		p.windowSize = msg
-		cmd := p.SetSize()
-		cmds = append(cmds, cmd)
-		p.markdownCache = make(map[string]string)
-		p.diffCache = make(map[string]string)
-		// case tea.KeyMsg:
-		// 	switch {
-		// 	case key.Matches(msg, permissionsKeys.Right) || key.Matches(msg, permissionsKeys.Tab):
-		// 		p.selectedOption = (p.selectedOption + 1) % 3
-		// 		return p, nil
-		// 	case key.Matches(msg, permissionsKeys.Left):
-		// 		p.selectedOption = (p.selectedOption + 2) % 3
-		// 	case key.Matches(msg, permissionsKeys.EnterSpace):
-		// 		return p, p.selectCurrentOption()
-		// 	case key.Matches(msg, permissionsKeys.Allow):
-		// 		return p, util.CmdHandler(PermissionResponseMsg{Action: PermissionAllow, Permission: p.permission})
-		// 	case key.Matches(msg, permissionsKeys.AllowSession):
-		// 		return p, util.CmdHandler(PermissionResponseMsg{Action: PermissionAllowForSession, Permission: p.permission})
-		// 	case key.Matches(msg, permissionsKeys.Deny):
-		// 		return p, util.CmdHandler(PermissionResponseMsg{Action: PermissionDeny, Permission: p.permission})
-		// 	default:
-		// 		// Pass other keys to viewport
-		// 		viewPort, cmd := p.contentViewPort.Update(msg)
-		// 		p.contentViewPort = viewPort
-		// 		cmds = append(cmds, cmd)
-		// 	}
+		p.width = msg.Width
+		p.height = msg.Height
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, permissionsKeys.Right) || key.Matches(msg, permissionsKeys.Tab):
+			p.selectedOption = (p.selectedOption + 1) % 3
+			return p, nil
+		case key.Matches(msg, permissionsKeys.Left):
+			p.selectedOption = (p.selectedOption + 2) % 3
+			return p, nil
+		case key.Matches(msg, permissionsKeys.EnterSpace):
+			return p, p.selectCurrentOption()
+		case key.Matches(msg, permissionsKeys.Allow):
+			return p, p.respond(PermissionAllow)
+		case key.Matches(msg, permissionsKeys.AllowSession):
+			return p, p.respond(PermissionAllowForSession)
+		case key.Matches(msg, permissionsKeys.Deny):
+			return p, p.respond(PermissionDeny)
+		default:
+			// scroll long diffs
+			viewPort, cmd := p.contentViewPort.Update(msg)
+			p.contentViewPort = viewPort
+			return p, cmd
+		}
 	}
+	return p, nil
+}
 
-	return p, tea.Batch(cmds...)
+func (p *permissionDialog) respond(action PermissionAction) tea.Cmd {
+	return util.CmdHandler(PermissionResponseMsg{Action: action, Permission: p.permission})
 }
 
-func (p *permissionDialogComponent) selectCurrentOption() tea.Cmd {
+func (p *permissionDialog) selectCurrentOption() tea.Cmd {
 	var action PermissionAction
 
 	switch p.selectedOption {
@@ -140,32 +159,27 @@ func (p *permissionDialogComponent) selectCurrentOption() tea.Cmd {
 		action = PermissionDeny
 	}
 
-	return util.CmdHandler(PermissionResponseMsg{Action: action}) // , Permission: p.permission})
+	return p.respond(action)
 }
 
-func (p *permissionDialogComponent) renderButtons() string {
+func (p *permissionDialog) renderButtons(width int) string {
 	t := theme.CurrentTheme()
-	baseStyle := styles.BaseStyle()
+	baseStyle := styles.BaseStyle().Background(t.BackgroundElement())
 
-	allowStyle := baseStyle
-	allowSessionStyle := baseStyle
-	denyStyle := baseStyle
-	spacerStyle := baseStyle.Background(t.Background())
+	allowStyle := baseStyle.Foreground(t.Primary())
+	allowSessionStyle := baseStyle.Foreground(t.Primary())
+	denyStyle := baseStyle.Foreground(t.Primary())
+	spacerStyle := baseStyle
 
 	// Style the selected button
+	selected := baseStyle.Background(t.Primary()).Foreground(t.BackgroundElement())
 	switch p.selectedOption {
 	case 0:
-		allowStyle = allowStyle.Background(t.Primary()).Foreground(t.Background())
-		allowSessionStyle = allowSessionStyle.Background(t.Background()).Foreground(t.Primary())
-		denyStyle = denyStyle.Background(t.Background()).Foreground(t.Primary())
+		allowStyle = selected
 	case 1:
-		allowStyle = allowStyle.Background(t.Background()).Foreground(t.Primary())
-		allowSessionStyle = allowSessionStyle.Background(t.Primary()).Foreground(t.Background())
-		denyStyle = denyStyle.Background(t.Background()).Foreground(t.Primary())
+		allowSessionStyle = selected
 	case 2:
-		allowStyle = allowStyle.Background(t.Background()).Foreground(t.Primary())
-		allowSessionStyle = allowSessionStyle.Background(t.Background()).Foreground(t.Primary())
-		denyStyle = denyStyle.Background(t.Primary()).Foreground(t.Background())
+		denyStyle = selected
 	}
 
 	allowButton := allowStyle.Padding(0, 1).Render("Allow (a)")
@@ -179,319 +193,135 @@ func (p *permissionDialogComponent) renderButtons() string {
 		allowSessionButton,
 		spacerStyle.Render("  "),
 		denyButton,
-		spacerStyle.Render("  "),
 	)
 
-	remainingWidth := p.width - lipgloss.Width(content)
+	remainingWidth := width - lipgloss.Width(content)
 	if remainingWidth > 0 {
 		content = spacerStyle.Render(strings.Repeat(" ", remainingWidth)) + content
 	}
 	return content
 }
 
-func (p *permissionDialogComponent) renderHeader() string {
-	return "NOT IMPLEMENTED"
-	// t := theme.CurrentTheme()
-	// baseStyle := styles.BaseStyle()
-	//
-	// toolKey := baseStyle.Foreground(t.TextMuted()).Bold(true).Render("Tool")
-	// toolValue := baseStyle.
-	// 	Foreground(t.Text()).
-	// 	Width(p.width - lipgloss.Width(toolKey)).
-	// 	Render(fmt.Sprintf(": %s", p.permission.ToolName))
-	//
-	// pathKey := baseStyle.Foreground(t.TextMuted()).Bold(true).Render("Path")
-	//
-	// // Get the current working directory to display relative path
-	// relativePath := p.permission.Path
-	// if filepath.IsAbs(relativePath) {
-	// 	if cwd, err := filepath.Rel(config.WorkingDirectory(), relativePath); err == nil {
-	// 		relativePath = cwd
-	// 	}
-	// }
-	//
-	// pathValue := baseStyle.
-	// 	Foreground(t.Text()).
-	// 	Width(p.width - lipgloss.Width(pathKey)).
-	// 	Render(fmt.Sprintf(": %s", relativePath))
-	//
-	// headerParts := []string{
-	// 	lipgloss.JoinHorizontal(
-	// 		lipgloss.Left,
-	// 		toolKey,
-	// 		toolValue,
-	// 	),
-	// 	baseStyle.Render(strings.Repeat(" ", p.width)),
-	// 	lipgloss.JoinHorizontal(
-	// 		lipgloss.Left,
-	// 		pathKey,
-	// 		pathValue,
-	// 	),
-	// 	baseStyle.Render(strings.Repeat(" ", p.width)),
-	// }
-	//
-	// // Add tool-specific header information
-	// switch p.permission.ToolName {
-	// case "bash":
-	// 	headerParts = append(headerParts, baseStyle.Foreground(t.TextMuted()).Width(p.width).Bold(true).Render("Command"))
-	// case "edit":
-	// 	headerParts = append(headerParts, baseStyle.Foreground(t.TextMuted()).Width(p.width).Bold(true).Render("Diff"))
-	// case "write":
-	// 	headerParts = append(headerParts, baseStyle.Foreground(t.TextMuted()).Width(p.width).Bold(true).Render("Diff"))
-	// case "fetch":
-	// 	headerParts = append(headerParts, baseStyle.Foreground(t.TextMuted()).Width(p.width).Bold(true).Render("URL"))
-	// }
-	//
-	// return lipgloss.NewStyle().Background(t.Background()).Render(lipgloss.JoinVertical(lipgloss.Left, headerParts...))
-}
-
-func (p *permissionDialogComponent) renderBashContent() string {
-	// t := theme.CurrentTheme()
-	// baseStyle := styles.BaseStyle()
-	//
-	// if pr, ok := p.permission.Params.(tools.BashPermissionsParams); ok {
-	// 	content := fmt.Sprintf("```bash\n%s\n```", pr.Command)
-	//
-	// 	// Use the cache for markdown rendering
-	// 	renderedContent := p.GetOrSetMarkdown(p.permission.ID, func() (string, error) {
-	// 		r := styles.GetMarkdownRenderer(p.width - 10)
-	// 		s, err := r.Render(content)
-	//    return s
-	// 	})
-	//
-	// 	finalContent := baseStyle.
-	// 		Width(p.contentViewPort.Width).
-	// 		Render(renderedContent)
-	// 	p.contentViewPort.SetContent(finalContent)
-	// 	return p.styleViewport()
-	// }
-	return ""
-}
-
-func (p *permissionDialogComponent) renderEditContent() string {
-	// if pr, ok := p.permission.Params.(tools.EditPermissionsParams); ok {
-	// 	diff := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
-	// 		return diff.FormatDiff(pr.Diff, diff.WithTotalWidth(p.contentViewPort.Width))
-	// 	})
-	//
-	// 	p.contentViewPort.SetContent(diff)
-	// 	return p.styleViewport()
-	// }
-	return ""
+func (p *permissionDialog) renderHeader(width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().Background(t.BackgroundElement())
+
+	title := baseStyle.
+		Foreground(t.Text()).
+		Bold(true).
+		Width(width).
+		Render(p.permission.Title)
+
+	lines := []string{title}
+	if waiting := len(p.app.Permissions) - 1; waiting > 0 {
+		text := fmt.Sprintf("%d more requests waiting", waiting)
+		if waiting == 1 {
+			text = "1 more request waiting"
+		}
+		lines = append(lines, baseStyle.
+			Foreground(t.TextMuted()).
+			Width(width).
+			Render(text))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
-func (p *permissionDialogComponent) renderPatchContent() string {
-	// if pr, ok := p.permission.Params.(tools.EditPermissionsParams); ok {
-	// 	diff := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
-	// 		return diff.FormatDiff(pr.Diff, diff.WithTotalWidth(p.contentViewPort.Width))
-	// 	})
-	//
-	// 	p.contentViewPort.SetContent(diff)
-	// 	return p.styleViewport()
-	// }
-	return ""
-}
+// renderContent renders the permission's metadata: a diff for file changes,
+// the command for shell commands and the remaining values as a list.
+func (p *permissionDialog) renderContent(width int) string {
+	if p.contentWidth == width {
+		return p.content
+	}
 
-func (p *permissionDialogComponent) renderWriteContent() string {
-	// if pr, ok := p.permission.Params.(tools.WritePermissionsParams); ok {
-	// 	// Use the cache for diff rendering
-	// 	diff := p.GetOrSetDiff(p.permission.ID, func() (string, error) {
-	// 		return diff.FormatDiff(pr.Diff, diff.WithTotalWidth(p.contentViewPort.Width))
-	// 	})
-	//
-	// 	p.contentViewPort.SetContent(diff)
-	// 	return p.styleViewport()
-	// }
-	return ""
-}
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().Background(t.BackgroundElement())
+	metadata := p.permission.Metadata
+	filename, _ := metadata["filePath"].(string)
+
+	var sections []string
+	if patch, ok := metadata["diff"].(string); ok && patch != "" {
+		formatted, err := diff.FormatUnifiedDiff(filename, patch, diff.WithWidth(width))
+		if err != nil {
+			formatted = baseStyle.Foreground(t.Error()).Render("Error formatting diff: " + err.Error())
+		}
+		sections = append(sections, strings.TrimRight(formatted, "\n"))
+	} else if command, ok := metadata["command"].(string); ok {
+		sections = append(sections, baseStyle.
+			Foreground(t.Text()).
+			Width(width).
+			Render("$ "+command))
+	}
 
-func (p *permissionDialogComponent) renderFetchContent() string {
-	// 	t := theme.CurrentTheme()
-	// 	baseStyle := styles.BaseStyle()
-	//
-	// 	if pr, ok := p.permission.Params.(tools.FetchPermissionsParams); ok {
-	// 		content := fmt.Sprintf("```bash\n%s\n```", pr.URL)
-	//
-	// 		// Use the cache for markdown rendering
-	// 		renderedContent := p.GetOrSetMarkdown(p.permission.ID, func() (string, error) {
-	// 			r := styles.GetMarkdownRenderer(p.width - 10)
-	// 			s, err := r.Render(content)
-	//      return s
-	// 		})
-	//
-	// 		finalContent := baseStyle.
-	// 			Width(p.contentViewPort.Width).
-	// 			Render(renderedContent)
-	// 		p.contentViewPort.SetContent(finalContent)
-	// 		return p.styleViewport()
-	// 	}
-	return ""
-}
+	// values already shown in the title or the diff
+	shown := map[string]bool{
+		"diff": true, "command": true, "filePath": true,
+		"oldString": true, "newString": true, "content": true,
+	}
+	var keys []string
+	for k := range metadata {
+		if !shown[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		sections = append(sections, baseStyle.
+			Foreground(t.TextMuted()).
+			Width(width).
+			Render(fmt.Sprintf("%s: %v", k, metadata[k])))
+	}
 
-func (p *permissionDialogComponent) renderDefaultContent() string {
-	// 	t := theme.CurrentTheme()
-	// 	baseStyle := styles.BaseStyle()
-	//
-	// 	content := p.permission.Description
-	//
-	// 	// Use the cache for markdown rendering
-	// 	renderedContent := p.GetOrSetMarkdown(p.permission.ID, func() (string, error) {
-	// 		r := styles.GetMarkdownRenderer(p.width - 10)
-	// 		s, err := r.Render(content)
-	//    return s
-	// 	})
-	//
-	// 	finalContent := baseStyle.
-	// 		Width(p.contentViewPort.Width).
-	// 		Render(renderedContent)
-	// 	p.contentViewPort.SetContent(finalContent)
-	//
-	// 	if renderedContent == "" {
-	// 		return ""
-	// 	}
-	//
-	return p.styleViewport()
+	p.content = strings.Join(sections, "\n")
+	p.contentWidth = width
+	return p.content
 }
 
-func (p *permissionDialogComponent) styleViewport() string {
+func (p *permissionDialog) View() string {
 	t := theme.CurrentTheme()
-	contentStyle := lipgloss.NewStyle().
-		Background(t.Background())
-
-	return contentStyle.Render(p.contentViewPort.View())
-}
+	baseStyle := styles.BaseStyle().Background(t.BackgroundElement())
+	width := layout.Current.Container.Width - 14
+
+	header := p.renderHeader(width)
+	buttons := p.renderButtons(width)
+	content := p.renderContent(width)
+
+	parts := []string{header}
+	if content != "" {
+		// leave room for the header, the buttons and the modal frame
+		maxHeight := max(3, layout.Current.Viewport.Height-lipgloss.Height(header)-lipgloss.Height(buttons)-10)
+		p.contentViewPort.SetWidth(width)
+		p.contentViewPort.SetHeight(min(lipgloss.Height(content), maxHeight))
+		p.contentViewPort.SetContent(content)
+		parts = append(parts, baseStyle.Width(width).Render(""), p.contentViewPort.View())
+	}
+	parts = append(parts, baseStyle.Width(width).Render(""), buttons)
 
-func (p *permissionDialogComponent) render() string {
-	return "NOT IMPLEMENTED"
-	// t := theme.CurrentTheme()
-	// baseStyle := styles.BaseStyle()
-	//
-	// title := baseStyle.
-	// 	Bold(true).
-	// 	Width(p.width - 4).
-	// 	Foreground(t.Primary()).
-	// 	Render("Permission Required")
-	// // Render header
-	// headerContent := p.renderHeader()
-	// // Render buttons
-	// buttons := p.renderButtons()
-	//
-	// // Calculate content height dynamically based on window size
-	// p.contentViewPort.Height = p.height - lipgloss.Height(headerContent) - lipgloss.Height(buttons) - 2 - lipgloss.Height(title)
-	// p.contentViewPort.Width = p.width - 4
-	//
-	// // Render content based on tool type
-	// var contentFinal string
-	// switch p.permission.ToolName {
-	// case "bash":
-	// 	contentFinal = p.renderBashContent()
-	// case "edit":
-	// 	contentFinal = p.renderEditContent()
-	// case "patch":
-	// 	contentFinal = p.renderPatchContent()
-	// case "write":
-	// 	contentFinal = p.renderWriteContent()
-	// case "fetch":
-	// 	contentFinal = p.renderFetchContent()
-	// default:
-	// 	contentFinal = p.renderDefaultContent()
-	// }
-	//
-	// content := lipgloss.JoinVertical(
-	// 	lipgloss.Top,
-	// 	title,
-	// 	baseStyle.Render(strings.Repeat(" ", lipgloss.Width(title))),
-	// 	headerContent,
-	// 	contentFinal,
-	// 	buttons,
-	// 	baseStyle.Render(strings.Repeat(" ", p.width-4)),
-	// )
-	//
-	// return baseStyle.
-	// 	Padding(1, 0, 0, 1).
-	// 	Border(lipgloss.RoundedBorder()).
-	// 	BorderBackground(t.Background()).
-	// 	BorderForeground(t.TextMuted()).
-	// 	Width(p.width).
-	// 	Height(p.height).
-	// 	Render(
-	// 		content,
-	// 	)
+	return lipgloss.JoinVertical(lipgloss.Left, parts...)
 }
 
-func (p *permissionDialogComponent) View() string {
-	return p.render()
+func (p *permissionDialog) Render(background string) string {
+	return p.modal.Render(p.View(), background)
 }
 
-func (p *permissionDialogComponent) SetSize() tea.Cmd {
-	// if p.permission.ID == "" {
-	// 	return nil
-	// }
-	// switch p.permission.ToolName {
-	// case "bash":
-	// 	p.width = int(float64(p.windowSize.Width) * 0.4)
-	// 	p.height = int(float64(p.windowSize.Height) * 0.3)
-	// case "edit":
-	// 	p.width = int(float64(p.windowSize.Width) * 0.8)
-	// 	p.height = int(float64(p.windowSize.Height) * 0.8)
-	// case "write":
-	// 	p.width = int(float64(p.windowSize.Width) * 0.8)
-	// 	p.height = int(float64(p.windowSize.Height) * 0.8)
-	// case "fetch":
-	// 	p.width = int(float64(p.windowSize.Width) * 0.4)
-	// 	p.height = int(float64(p.windowSize.Height) * 0.3)
-	// default:
-	// 	p.width = int(float64(p.windowSize.Width) * 0.7)
-	// 	p.height = int(float64(p.windowSize.Height) * 0.5)
-	// }
+func (p *permissionDialog) Close() tea.Cmd {
 	return nil
 }
 
-// func (p *permissionDialogCmp) SetPermissions(permission permission.PermissionRequest) tea.Cmd {
-// 	p.permission = permission
-// 	return p.SetSize()
-// }
-
-// Helper to get or set cached diff content
-func (c *permissionDialogComponent) GetOrSetDiff(key string, generator func() (string, error)) string {
-	if cached, ok := c.diffCache[key]; ok {
-		return cached
-	}
-
-	content, err := generator()
-	if err != nil {
-		return fmt.Sprintf("Error formatting diff: %v", err)
-	}
-
-	c.diffCache[key] = content
-
-	return content
+func (p *permissionDialog) Permission() client.PermissionInfo {
+	return p.permission
 }
 
-// Helper to get or set cached markdown content
-func (c *permissionDialogComponent) GetOrSetMarkdown(key string, generator func() (string, error)) string {
-	if cached, ok := c.markdownCache[key]; ok {
-		return cached
+// NewPermissionDialog creates a dialog for the oldest pending permission
+// request, or returns nil if there is none
+func NewPermissionDialog(app *app.App) PermissionDialog {
+	if len(app.Permissions) == 0 {
+		return nil
 	}
-
-	content, err := generator()
-	if err != nil {
-		return fmt.Sprintf("Error rendering markdown: %v", err)
-	}
-
-	c.markdownCache[key] = content
-
-	return content
-}
-
-func NewPermissionDialogCmp() PermissionDialogComponent {
-	// Create viewport for content
-	contentViewport := viewport.New() // (0, 0)
-
-	return &permissionDialogComponent{
-		contentViewPort: contentViewport,
+	return &permissionDialog{
+		app:             app,
+		permission:      app.Permissions[0],
+		modal:           modal.New(modal.WithTitle("Permission Required"), modal.WithMaxWidth(80)),
+		contentViewPort: viewport.New(),
 		selectedOption:  0, // Default to "Allow"
-		diffCache:       make(map[string]string),
-		markdownCache:   make(map[string]string),
 	}
 }
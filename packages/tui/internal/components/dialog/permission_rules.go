@@ -0,0 +1,235 @@
+package dialog
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/config"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/status"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+)
+
+// PermissionRulesDialog interface for the dialog listing the permission rules
+type PermissionRulesDialog interface {
+	layout.Modal
+}
+
+type ruleItem struct {
+	rule config.PermissionRule
+}
+
+func (r ruleItem) Render(selected bool, width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().
+		Width(width - 2).
+		Background(t.BackgroundElement())
+
+	if selected {
+		baseStyle = baseStyle.
+			Background(t.Primary()).
+			Foreground(t.BackgroundElement()).
+			Bold(true)
+	} else if r.rule.Action == config.PermissionActionDeny {
+		baseStyle = baseStyle.
+			Foreground(t.Error())
+	} else {
+		baseStyle = baseStyle.
+			Foreground(t.Text())
+	}
+
+	pattern := r.rule.Pattern
+	if pattern == "" {
+		pattern = "*"
+	}
+	return baseStyle.Padding(0, 1).Render(fmt.Sprintf("%-5s  %-16s  %s", r.rule.Action, r.rule.Tool, pattern))
+}
+
+type permissionRulesDialog struct {
+	width  int
+	height int
+
+	app   *app.App
+	modal *modal.Modal
+	list  list.List[ruleItem]
+	input textinput.Model
+	// editing is set while the input is shown, editIndex is the rule being
+	// edited or -1 for a new rule
+	editing   bool
+	editIndex int
+}
+
+// Escapes keeps the dialog open while a rule is edited, esc cancels the edit
+func (p *permissionRulesDialog) Escapes() bool {
+	return p.editing
+}
+
+func (p *permissionRulesDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (p *permissionRulesDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.width = msg.Width
+		p.height = msg.Height
+		p.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case tea.KeyMsg:
+		if p.editing {
+			switch msg.String() {
+			case "enter":
+				p.save()
+				return p, nil
+			case "esc":
+				p.editing = false
+				p.input.Blur()
+				return p, nil
+			}
+			var cmd tea.Cmd
+			p.input, cmd = p.input.Update(msg)
+			return p, cmd
+		}
+
+		switch msg.String() {
+		case "n", "a":
+			p.edit(-1)
+			return p, nil
+		case "enter", "e":
+			if _, idx := p.list.GetSelectedItem(); idx >= 0 {
+				p.edit(idx)
+			}
+			return p, nil
+		case "x", "delete", "backspace":
+			if item, idx := p.list.GetSelectedItem(); idx >= 0 {
+				rules := p.app.Config.Permissions
+				p.app.Config.Permissions = slices.Delete(slices.Clone(rules), idx, idx+1)
+				p.app.SaveConfig()
+				status.Info("Removed permission rule: " + item.rule.String())
+				p.refresh(idx)
+			}
+			return p, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	listModel, cmd := p.list.Update(msg)
+	p.list = listModel.(list.List[ruleItem])
+	return p, cmd
+}
+
+func (p *permissionRulesDialog) edit(idx int) {
+	p.editing = true
+	p.editIndex = idx
+	p.input.Reset()
+	if idx >= 0 {
+		p.input.SetValue(p.app.Config.Permissions[idx].String())
+	}
+	p.input.CursorEnd()
+	p.input.Focus()
+}
+
+// save parses the input and stores the rule in the config file
+func (p *permissionRulesDialog) save() {
+	rule, err := config.ParsePermissionRule(p.input.Value())
+	if err != nil {
+		status.Error("Invalid permission rule: " + err.Error())
+		return
+	}
+
+	rules := slices.Clone(p.app.Config.Permissions)
+	idx := p.editIndex
+	if idx >= 0 && idx < len(rules) {
+		rules[idx] = rule
+	} else {
+		rules = append(rules, rule)
+		idx = len(rules) - 1
+	}
+	p.app.Config.Permissions = rules
+	p.app.SaveConfig()
+
+	p.editing = false
+	p.input.Blur()
+	p.refresh(idx)
+}
+
+func (p *permissionRulesDialog) refresh(selected int) {
+	p.list.SetItems(ruleItems(p.app))
+	if items := p.list.GetItems(); len(items) > 0 {
+		p.list.SetSelectedIndex(min(max(selected, 0), len(items)-1))
+	}
+}
+
+func (p *permissionRulesDialog) View() string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 14
+	base := styles.BaseStyle().Background(t.BackgroundElement())
+	muted := base.Foreground(t.TextMuted())
+	bold := base.Foreground(t.Text()).Bold(true)
+
+	lines := []string{p.list.View(), base.Width(width).Render("")}
+	if p.editing {
+		p.input.SetWidth(width - 4)
+		lines = append(lines,
+			base.Width(width).Render(p.input.View()),
+			muted.Width(width).Render("allow|deny tool [pattern], e.g. allow opencode_edit src/**"),
+			base.Width(width).Render(bold.Render("enter")+muted.Render(" save  ")+bold.Render("esc")+muted.Render(" cancel")),
+		)
+	} else {
+		lines = append(lines, base.Width(width).Render(
+			bold.Render("n")+muted.Render(" new  ")+
+				bold.Render("e")+muted.Render(" edit  ")+
+				bold.Render("x")+muted.Render(" delete"),
+		))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (p *permissionRulesDialog) Render(background string) string {
+	return p.modal.Render(p.View(), background)
+}
+
+func (p *permissionRulesDialog) Close() tea.Cmd {
+	return nil
+}
+
+func ruleItems(app *app.App) []ruleItem {
+	var items []ruleItem
+	for _, rule := range app.Config.Permissions {
+		items = append(items, ruleItem{rule: rule})
+	}
+	return items
+}
+
+// NewPermissionRulesDialog creates a dialog to list, add, edit and delete the
+// permission rules in the config
+func NewPermissionRulesDialog(app *app.App) PermissionRulesDialog {
+	t := theme.CurrentTheme()
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Styles.Focused.Prompt = lipgloss.NewStyle().Background(t.BackgroundElement()).Foreground(t.Primary())
+	input.Styles.Focused.Text = lipgloss.NewStyle().Background(t.BackgroundElement()).Foreground(t.Text())
+	input.Styles.Blurred.Prompt = lipgloss.NewStyle().Background(t.BackgroundElement()).Foreground(t.TextMuted())
+	input.Styles.Blurred.Text = lipgloss.NewStyle().Background(t.BackgroundElement()).Foreground(t.TextMuted())
+	input.Styles.Cursor.Color = t.Primary()
+
+	list := list.NewListComponent(
+		ruleItems(app),
+		10, // maxVisibleItems
+		"No permission rules",
+		false, // useAlphaNumericKeys
+	)
+
+	return &permissionRulesDialog{
+		app:   app,
+		list:  list,
+		input: input,
+		modal: modal.New(modal.WithTitle("Permission Rules"), modal.WithMaxWidth(80)),
+	}
+}
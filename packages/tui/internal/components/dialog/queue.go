@@ -0,0 +1,151 @@
+package dialog
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/state"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// EditQueuedPromptMsg is sent when a queued prompt is taken out of the queue
+// to be edited in the editor
+type EditQueuedPromptMsg struct {
+	Prompt app.QueuedPrompt
+}
+
+// QueueDialog interface for the queued prompts dialog
+type QueueDialog interface {
+	layout.Modal
+}
+
+type queueItem struct {
+	prompt app.QueuedPrompt
+}
+
+func (q queueItem) Render(selected bool, width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().
+		Width(width - 2).
+		Background(t.BackgroundElement())
+
+	if selected {
+		baseStyle = baseStyle.
+			Background(t.Primary()).
+			Foreground(t.BackgroundElement()).
+			Bold(true)
+	} else {
+		baseStyle = baseStyle.
+			Foreground(t.Text())
+	}
+
+	text, _, _ := strings.Cut(strings.TrimSpace(q.prompt.Text), "\n")
+	if len(q.prompt.Attachments) > 0 {
+		text = fmt.Sprintf("%s [%d attachment(s)]", text, len(q.prompt.Attachments))
+	}
+	text = ansi.Truncate(text, max(0, width-4), "...")
+	return baseStyle.Padding(0, 1).Render(text)
+}
+
+type queueDialog struct {
+	width  int
+	height int
+
+	app   *app.App
+	modal *modal.Modal
+	list  list.List[queueItem]
+}
+
+func (q *queueDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (q *queueDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		q.width = msg.Width
+		q.height = msg.Height
+		q.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "enter", "e":
+			// take the prompt out of the queue and put it back in the editor
+			if item, idx := q.list.GetSelectedItem(); idx >= 0 {
+				prompt, ok := q.app.RemoveQueued(q.app.Session.Id, item.prompt.ID)
+				if !ok {
+					return q, nil
+				}
+				return q, tea.Sequence(
+					util.CmdHandler(modal.CloseModalMsg{}),
+					util.CmdHandler(state.QueueUpdatedMsg{SessionID: q.app.Session.Id}),
+					util.CmdHandler(EditQueuedPromptMsg{Prompt: prompt}),
+				)
+			}
+		case "x", "delete", "backspace":
+			if item, idx := q.list.GetSelectedItem(); idx >= 0 {
+				q.app.RemoveQueued(q.app.Session.Id, item.prompt.ID)
+				q.list.SetItems(queueItems(q.app))
+				if q.list.IsEmpty() {
+					return q, tea.Sequence(
+						util.CmdHandler(modal.CloseModalMsg{}),
+						util.CmdHandler(state.QueueUpdatedMsg{SessionID: q.app.Session.Id}),
+					)
+				}
+				q.list.SetSelectedIndex(min(idx, len(q.list.GetItems())-1))
+				return q, util.CmdHandler(state.QueueUpdatedMsg{SessionID: q.app.Session.Id})
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	listModel, cmd := q.list.Update(msg)
+	q.list = listModel.(list.List[queueItem])
+	return q, cmd
+}
+
+func (q *queueDialog) Render(background string) string {
+	t := theme.CurrentTheme()
+	muted := styles.BaseStyle().
+		Background(t.BackgroundElement()).
+		Foreground(t.TextMuted()).
+		Padding(1, 1, 0, 1).
+		Render("enter edit • x cancel")
+	return q.modal.Render(q.list.View()+"\n"+muted, background)
+}
+
+func (q *queueDialog) Close() tea.Cmd {
+	return nil
+}
+
+func queueItems(app *app.App) []queueItem {
+	var items []queueItem
+	for _, prompt := range app.Queued(app.Session.Id) {
+		items = append(items, queueItem{prompt: prompt})
+	}
+	return items
+}
+
+// NewQueueDialog creates a dialog for editing and cancelling the prompts
+// queued in the current session
+func NewQueueDialog(app *app.App) QueueDialog {
+	list := list.NewListComponent(
+		queueItems(app),
+		10, // maxVisibleItems
+		"No queued prompts",
+		false, // useAlphaNumericKeys
+	)
+
+	return &queueDialog{
+		app:   app,
+		list:  list,
+		modal: modal.New(modal.WithTitle("Queued Prompts"), modal.WithMaxWidth(80)),
+	}
+}
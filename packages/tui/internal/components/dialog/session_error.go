@@ -0,0 +1,306 @@
+package dialog
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/app"
+	"github.com/sst/opencode/internal/components/list"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/state"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+	"github.com/sst/opencode/pkg/client"
+)
+
+// defaultRetryDelay is how long to wait after a rate limit error that
+// doesn't say when to try again
+const defaultRetryDelay = 30 * time.Second
+
+// rateLimitPattern matches the error names and phrases providers use for rate
+// limits and overloads, and the 429 and 529 status codes where they are
+// given as a status rather than any number in the message
+var rateLimitPattern = regexp.MustCompile(`(?i)\b(?:rate[ _-]?limit\w*|too many requests|overloaded\w*)\b|(?:\bstatus(?:[ _]?code)?\W{0,3}|\bhttp\W{0,2}|\()(?:429|529)\b`)
+
+var retryAfterPattern = regexp.MustCompile(`(?i)(?:retry|try again)\D{0,20}(\d+(?:\.\d+)?)\s*(ms|milliseconds?|s|secs?|seconds?|m|mins?|minutes?)?\b`)
+
+// RetryPromptMsg asks to send the last prompt of the current session again
+type RetryPromptMsg struct{}
+
+type retryTickMsg struct{}
+
+// SessionErrorDialog interface for the dialog explaining a failed prompt
+type SessionErrorDialog interface {
+	layout.Modal
+}
+
+type errorOption struct {
+	label    string
+	provider *client.ProviderInfo
+	model    *client.ModelInfo
+}
+
+func (o errorOption) Render(selected bool, width int) string {
+	t := theme.CurrentTheme()
+	baseStyle := styles.BaseStyle().
+		Width(width - 2).
+		Background(t.BackgroundElement())
+
+	if selected {
+		baseStyle = baseStyle.
+			Background(t.Primary()).
+			Foreground(t.BackgroundElement()).
+			Bold(true)
+	} else {
+		baseStyle = baseStyle.
+			Foreground(t.Text())
+	}
+	return baseStyle.Padding(0, 1).Render(o.label)
+}
+
+type sessionErrorDialog struct {
+	width  int
+	height int
+
+	modal   *modal.Modal
+	list    list.List[errorOption]
+	message string
+	// env lists the variables that configure the provider of an auth error
+	env []string
+	// retryAt is when a rate limited prompt is retried, zero for errors
+	// that aren't retried automatically
+	retryAt    time.Time
+	retryLabel string
+
+	app *app.App
+	// failedID is the provider to move away from, authMessage is set for
+	// auth errors
+	failedID    string
+	authMessage string
+}
+
+type errorProvidersMsg struct {
+	providers []client.ProviderInfo
+	defaults  map[string]string
+}
+
+func (s *sessionErrorDialog) Init() tea.Cmd {
+	app := s.app
+	loadProviders := func() tea.Msg {
+		providers, defaults, err := app.ListProviderDefaults(context.Background())
+		if err != nil {
+			slog.Error("Failed to list providers", "error", err)
+			return nil
+		}
+		return errorProvidersMsg{providers: providers, defaults: defaults}
+	}
+	if s.retryAt.IsZero() {
+		return loadProviders
+	}
+	return tea.Batch(loadProviders, retryTick())
+}
+
+func (s *sessionErrorDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+		s.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	case errorProvidersMsg:
+		s.setProviders(msg.providers, msg.defaults)
+		return s, nil
+	case retryTickMsg:
+		if s.retryAt.IsZero() {
+			return s, nil
+		}
+		if !time.Now().Before(s.retryAt) {
+			return s, s.choose(errorOption{})
+		}
+		return s, retryTick()
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "r":
+			return s, s.choose(errorOption{})
+		case "enter":
+			if option, idx := s.list.GetSelectedItem(); idx >= 0 {
+				return s, s.choose(option)
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	listModel, cmd := s.list.Update(msg)
+	s.list = listModel.(list.List[errorOption])
+	return s, cmd
+}
+
+// choose closes the dialog and retries the prompt, after switching to the
+// option's model if it has one
+func (s *sessionErrorDialog) choose(option errorOption) tea.Cmd {
+	s.retryAt = time.Time{}
+	cmds := []tea.Cmd{util.CmdHandler(modal.CloseModalMsg{})}
+	if option.provider != nil && option.model != nil {
+		cmds = append(cmds, util.CmdHandler(state.ModelSelectedMsg{
+			Provider: *option.provider,
+			Model:    *option.model,
+		}))
+	}
+	cmds = append(cmds, util.CmdHandler(RetryPromptMsg{}))
+	return tea.Sequence(cmds...)
+}
+
+func (s *sessionErrorDialog) View() string {
+	t := theme.CurrentTheme()
+	width := layout.Current.Container.Width - 14
+	base := styles.BaseStyle().Background(t.BackgroundElement())
+	muted := base.Foreground(t.TextMuted())
+	bold := base.Foreground(t.Text()).Bold(true)
+
+	lines := []string{base.Foreground(t.Error()).Width(width).Render(s.message)}
+	if len(s.env) > 0 {
+		lines = append(lines,
+			base.Width(width).Render(""),
+			base.Foreground(t.Text()).Width(width).Render("Set one of these environment variables and restart opencode:"),
+		)
+		for _, env := range s.env {
+			lines = append(lines, base.Foreground(t.Primary()).Width(width).Render("  "+env))
+		}
+	}
+	if !s.retryAt.IsZero() {
+		seconds := int(time.Until(s.retryAt).Round(time.Second).Seconds())
+		lines = append(lines,
+			base.Width(width).Render(""),
+			base.Foreground(t.Warning()).Width(width).Render(fmt.Sprintf("Retrying in %ds", max(0, seconds))),
+		)
+	}
+	lines = append(lines,
+		base.Width(width).Render(""),
+		s.list.View(),
+		base.Width(width).Render(""),
+		base.Width(width).Render(bold.Render("enter")+muted.Render(" select  ")+bold.Render("r")+muted.Render(" retry  ")+bold.Render("esc")+muted.Render(" dismiss")),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+func (s *sessionErrorDialog) Render(background string) string {
+	return s.modal.Render(s.View(), background)
+}
+
+func (s *sessionErrorDialog) Close() tea.Cmd {
+	return nil
+}
+
+func retryTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return retryTickMsg{}
+	})
+}
+
+// rateLimitDelay reports whether an error message looks like a rate limit or
+// overload error, and how long to wait before retrying
+func rateLimitDelay(message string) (time.Duration, bool) {
+	if !rateLimitPattern.MatchString(message) {
+		return 0, false
+	}
+
+	match := retryAfterPattern.FindStringSubmatch(message)
+	if match == nil {
+		return defaultRetryDelay, true
+	}
+	n, _ := strconv.ParseFloat(match[1], 64)
+	unit := time.Second
+	switch strings.ToLower(match[2]) {
+	case "ms", "millisecond", "milliseconds":
+		unit = time.Millisecond
+	case "m", "min", "mins", "minute", "minutes":
+		unit = time.Minute
+	}
+	return max(time.Duration(n*float64(unit)), time.Second), true
+}
+
+// NewSessionErrorDialog creates a dialog for an error that ended a prompt of
+// the current session. It offers to retry the prompt, or to switch to
+// another configured provider and retry there. Rate limited prompts are
+// retried automatically after a countdown. The providers to switch to are
+// listed once Init has loaded them.
+func NewSessionErrorDialog(app *app.App, sessionError client.EventSessionError_Properties_Error) SessionErrorDialog {
+	dialog := &sessionErrorDialog{app: app, retryLabel: "Retry"}
+	title := "Session Error"
+	if app.Provider != nil {
+		dialog.failedID = app.Provider.Id
+	}
+
+	value, _ := sessionError.ValueByDiscriminator()
+	switch value := value.(type) {
+	case client.ProviderAuthError:
+		title = "Authentication Failed"
+		dialog.failedID = value.Data.ProviderID
+		dialog.authMessage = value.Data.Message
+		dialog.message = fmt.Sprintf("%s rejected the request: %s", value.Data.ProviderID, value.Data.Message)
+	case client.UnknownError:
+		dialog.message = value.Data.Message
+		if delay, ok := rateLimitDelay(value.Data.Message); ok {
+			title = "Rate Limited"
+			dialog.retryLabel = "Retry now"
+			dialog.retryAt = time.Now().Add(delay)
+		}
+	default:
+		dialog.message = "The request failed"
+	}
+
+	dialog.list = list.NewListComponent(
+		[]errorOption{{label: dialog.retryLabel}},
+		10, // maxVisibleItems
+		"",
+		false, // useAlphaNumericKeys
+	)
+	dialog.list.SetMaxWidth(layout.Current.Container.Width - 12)
+	dialog.modal = modal.New(modal.WithTitle(title), modal.WithMaxWidth(80))
+	return dialog
+}
+
+// setProviders names the provider of an auth error and adds an option for
+// every other provider
+func (s *sessionErrorDialog) setProviders(providers []client.ProviderInfo, defaults map[string]string) {
+	options := []errorOption{{label: s.retryLabel}}
+	slices.SortFunc(providers, func(a, b client.ProviderInfo) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, provider := range providers {
+		if provider.Id == s.failedID {
+			if s.authMessage != "" {
+				s.message = fmt.Sprintf("%s rejected the request: %s", provider.Name, s.authMessage)
+				s.env = provider.Env
+			}
+			continue
+		}
+		if len(provider.Models) == 0 {
+			continue
+		}
+		model, ok := provider.Models[defaults[provider.Id]]
+		if !ok {
+			// fall back to the first model by name
+			model = slices.SortedFunc(maps.Values(provider.Models), func(a, b client.ModelInfo) int {
+				return cmp.Compare(a.Name, b.Name)
+			})[0]
+		}
+		options = append(options, errorOption{
+			label:    fmt.Sprintf("Switch to %s (%s)", provider.Name, model.Name),
+			provider: &provider,
+			model:    &model,
+		})
+	}
+	s.list.SetItems(options)
+}
@@ -0,0 +1,81 @@
+package dialog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitDelay(t *testing.T) {
+	tests := []struct {
+		name      string
+		message   string
+		wantDelay time.Duration
+		wantOK    bool
+	}{
+		{name: "rate limit error name", message: "rate_limit_error: slow down", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "rate limited phrase", message: "You have been rate-limited", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "too many requests", message: "Too Many Requests", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "overloaded", message: "overloaded_error: Overloaded", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "status code 429", message: "request failed with status code 429", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "status 529", message: "status: 529", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "http 429", message: "HTTP 429", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "parenthesized 429", message: "request failed (429)", wantDelay: defaultRetryDelay, wantOK: true},
+		{name: "429 inside a number", message: "context length 14290 exceeds the limit", wantOK: false},
+		{name: "429 as a count", message: "read 429 bytes", wantOK: false},
+		{name: "529 in a request id", message: "request req_529abc failed", wantOK: false},
+		{name: "status 4290", message: "status 4290", wantOK: false},
+		{name: "unrelated error", message: "invalid api key", wantOK: false},
+		{name: "retry after seconds", message: "rate limit exceeded, retry after 20s", wantDelay: 20 * time.Second, wantOK: true},
+		{name: "try again in seconds", message: "Rate limit reached. Please try again in 7 seconds.", wantDelay: 7 * time.Second, wantOK: true},
+		{name: "retry in minutes", message: "too many requests, retry in 2 minutes", wantDelay: 2 * time.Minute, wantOK: true},
+		{name: "retry in fractional seconds", message: "Rate limit reached. Please try again in 2.5s.", wantDelay: 2500 * time.Millisecond, wantOK: true},
+		{name: "retry without unit", message: "overloaded, retry after 5", wantDelay: 5 * time.Second, wantOK: true},
+		{name: "retry in milliseconds is at least a second", message: "rate limited, retry in 250ms", wantDelay: time.Second, wantOK: true},
+		{name: "retry hint without rate limit", message: "connection reset, retry in 5s", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := rateLimitDelay(tt.message)
+			if ok != tt.wantOK {
+				t.Fatalf("rateLimitDelay(%q) ok = %v, want %v", tt.message, ok, tt.wantOK)
+			}
+			if delay != tt.wantDelay {
+				t.Errorf("rateLimitDelay(%q) = %v, want %v", tt.message, delay, tt.wantDelay)
+			}
+		})
+	}
+}
+
+func TestRetryAfterPattern(t *testing.T) {
+	tests := []struct {
+		message string
+		want    []string
+	}{
+		{message: "retry after 20s", want: []string{"20", "s"}},
+		{message: "Retry-After: 30", want: []string{"30", ""}},
+		{message: "please try again in 1.5 seconds", want: []string{"1.5", "seconds"}},
+		{message: "try again in 3 mins", want: []string{"3", "mins"}},
+		{message: "retry in 100 milliseconds", want: []string{"100", "milliseconds"}},
+		{message: "retry in 10 seconds", want: []string{"10", "seconds"}},
+		{message: "rate limited", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			match := retryAfterPattern.FindStringSubmatch(tt.message)
+			if tt.want == nil {
+				if match != nil {
+					t.Errorf("matched %q, want no match", match[0])
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("no match, want %q", tt.want)
+			}
+			if match[1] != tt.want[0] || match[2] != tt.want[1] {
+				t.Errorf("got (%q, %q), want (%q, %q)", match[1], match[2], tt.want[0], tt.want[1])
+			}
+		})
+	}
+}
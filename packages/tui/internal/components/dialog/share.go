@@ -0,0 +1,99 @@
+package dialog
+
+import (
+	"github.com/atotto/clipboard"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+	"github.com/sst/opencode/internal/commands"
+	"github.com/sst/opencode/internal/components/modal"
+	"github.com/sst/opencode/internal/components/qr"
+	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/status"
+	"github.com/sst/opencode/internal/styles"
+	"github.com/sst/opencode/internal/theme"
+	"github.com/sst/opencode/internal/util"
+)
+
+// ShareDialog interface for the dialog showing a shared session's link
+type ShareDialog interface {
+	layout.Modal
+}
+
+type shareDialog struct {
+	width  int
+	height int
+	url    string
+	modal  *modal.Modal
+}
+
+func (s *shareDialog) Init() tea.Cmd {
+	return nil
+}
+
+func (s *shareDialog) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		s.width = msg.Width
+		s.height = msg.Height
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "c", "y":
+			CopyToClipboard(s.url)
+		case "u":
+			return s, tea.Sequence(
+				util.CmdHandler(modal.CloseModalMsg{}),
+				util.CmdHandler(commands.ExecuteCommandMsg{Name: "unshare"}),
+			)
+		case "enter", "q":
+			return s, util.CmdHandler(modal.CloseModalMsg{})
+		}
+	}
+	return s, nil
+}
+
+func (s *shareDialog) View() string {
+	t := theme.CurrentTheme()
+	base := styles.BaseStyle().Background(t.BackgroundElement())
+	muted := base.Foreground(t.TextMuted()).Render
+	bold := base.Foreground(t.Text()).Bold(true).Render
+
+	lines := []string{}
+	// leave the QR code out when it doesn't fit, the link is enough
+	code, size, err := qr.Generate(s.url)
+	if err == nil && size+4 <= layout.Current.Viewport.Width && size/2+8 <= layout.Current.Viewport.Height {
+		lines = append(lines, code)
+	}
+	lines = append(lines,
+		base.Foreground(t.Primary()).Render(s.url),
+		"",
+		bold("c")+muted(" copy link  ")+bold("u")+muted(" unshare  ")+bold("esc")+muted(" close"),
+	)
+
+	return lipgloss.JoinVertical(lipgloss.Center, lines...)
+}
+
+func (s *shareDialog) Render(background string) string {
+	return s.modal.Render(s.View(), background)
+}
+
+func (s *shareDialog) Close() tea.Cmd {
+	return nil
+}
+
+// CopyToClipboard copies text to the system clipboard and reports the result
+// in the status bar.
+func CopyToClipboard(text string) {
+	if err := clipboard.WriteAll(text); err != nil {
+		status.Warn("Could not copy to the clipboard: " + err.Error())
+		return
+	}
+	status.Info("Copied to the clipboard")
+}
+
+// NewShareDialog creates a dialog showing the link of a shared session
+func NewShareDialog(url string) ShareDialog {
+	return &shareDialog{
+		url:   url,
+		modal: modal.New(modal.WithTitle("Share Session")),
+	}
+}
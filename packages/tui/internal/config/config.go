@@ -5,14 +5,86 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 
 	"github.com/BurntSushi/toml"
+	"github.com/bmatcuk/doublestar/v4"
 )
 
 type Config struct {
 	Theme    string `toml:"theme"`
 	Provider string `toml:"provider"`
 	Model    string `toml:"model"`
+	// LogLevel is one of debug, info, warn or error, debug by default.
+	// OPENCODE_LOG_LEVEL takes precedence.
+	LogLevel string `toml:"log_level,omitempty"`
+	// LogFormat is text or json. OPENCODE_LOG_FORMAT takes precedence.
+	LogFormat string `toml:"log_format,omitempty"`
+	// KeepModel keeps the current model when switching sessions instead of
+	// switching to the model the session was last used with.
+	KeepModel bool `toml:"keep_model,omitempty"`
+	// AutoCompact summarizes the session once the input of the last
+	// response passes AutoCompactThreshold of the model's context window.
+	AutoCompact bool `toml:"auto_compact,omitempty"`
+	// AutoCompactThreshold is a fraction of the context window, 0.8 if unset.
+	AutoCompactThreshold float64 `toml:"auto_compact_threshold,omitempty"`
+	// ShowThinking expands the reasoning of reasoning models by default.
+	ShowThinking bool `toml:"show_thinking,omitempty"`
+	// Permissions answer permission requests without asking. They are
+	// written as [[permission]] tables.
+	Permissions []PermissionRule `toml:"permission,omitempty"`
+}
+
+// PermissionRule allows or denies the permission requests of a tool whose
+// file path or command matches a pattern.
+type PermissionRule struct {
+	// Tool is the name of the tool, such as opencode_edit or opencode_bash.
+	Tool string `toml:"tool"`
+	// Pattern is a doublestar pattern matched against the file path,
+	// relative to the project root, or against the command. A trailing *
+	// in a command pattern also matches arguments with slashes. An empty
+	// pattern matches every request of the tool.
+	Pattern string `toml:"pattern,omitempty"`
+	// Action is allow or deny.
+	Action string `toml:"action"`
+}
+
+const (
+	PermissionActionAllow = "allow"
+	PermissionActionDeny  = "deny"
+)
+
+func (r PermissionRule) String() string {
+	return strings.TrimSpace(r.Action + " " + r.Tool + " " + r.Pattern)
+}
+
+// ParsePermissionRule parses a rule written as "action tool [pattern]", for
+// example "allow opencode_edit src/**".
+func ParsePermissionRule(text string) (PermissionRule, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 2 {
+		return PermissionRule{}, fmt.Errorf("expected \"allow|deny tool [pattern]\"")
+	}
+	rule := PermissionRule{
+		Action:  fields[0],
+		Tool:    fields[1],
+		Pattern: strings.Join(fields[2:], " "),
+	}
+	return rule, rule.Validate()
+}
+
+// Validate checks the action and the pattern of the rule.
+func (r PermissionRule) Validate() error {
+	if r.Action != PermissionActionAllow && r.Action != PermissionActionDeny {
+		return fmt.Errorf("unknown action %q, expected allow or deny", r.Action)
+	}
+	if r.Tool == "" {
+		return fmt.Errorf("missing tool")
+	}
+	if r.Pattern != "" && !doublestar.ValidatePattern(r.Pattern) {
+		return fmt.Errorf("invalid pattern %q", r.Pattern)
+	}
+	return nil
 }
 
 // NewConfig creates a new Config instance with default values.
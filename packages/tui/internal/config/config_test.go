@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+func TestParsePermissionRule(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    PermissionRule
+		wantErr bool
+	}{
+		{
+			text: "allow opencode_edit src/**",
+			want: PermissionRule{Action: "allow", Tool: "opencode_edit", Pattern: "src/**"},
+		},
+		{
+			text: "deny opencode_bash",
+			want: PermissionRule{Action: "deny", Tool: "opencode_bash"},
+		},
+		{
+			text: "  deny   opencode_bash   rm  -rf*  ",
+			want: PermissionRule{Action: "deny", Tool: "opencode_bash", Pattern: "rm -rf*"},
+		},
+		{text: "", wantErr: true},
+		{text: "allow", wantErr: true},
+		{text: "ask opencode_edit", wantErr: true},
+		{text: "Allow opencode_edit", wantErr: true},
+		{text: "allow opencode_edit src/[", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParsePermissionRule(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePermissionRule(%q) error = %v, wantErr %v", tt.text, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParsePermissionRule(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermissionRuleString(t *testing.T) {
+	tests := []struct {
+		rule PermissionRule
+		want string
+	}{
+		{rule: PermissionRule{Action: "allow", Tool: "opencode_edit", Pattern: "src/**"}, want: "allow opencode_edit src/**"},
+		{rule: PermissionRule{Action: "deny", Tool: "opencode_bash"}, want: "deny opencode_bash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.rule.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			// a rule reads back as itself
+			if parsed, err := ParsePermissionRule(tt.rule.String()); err != nil || parsed != tt.rule {
+				t.Errorf("ParsePermissionRule(String()) = %+v, %v", parsed, err)
+			}
+		})
+	}
+}
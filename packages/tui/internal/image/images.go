@@ -2,10 +2,14 @@ package image
 
 import (
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"image"
 	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
 	"image/png"
+	"io"
 	"os"
 	"strings"
 
@@ -28,6 +32,21 @@ func ValidateFileSize(filePath string, sizeLimit int64) (bool, error) {
 	return false, nil
 }
 
+// ValidateReaderSize reads r up to sizeLimit bytes. It reports whether the
+// content was larger than the limit, in which case the data is truncated.
+func ValidateReaderSize(r io.Reader, sizeLimit int64) ([]byte, bool, error) {
+	data, err := io.ReadAll(io.LimitReader(r, sizeLimit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading content: %w", err)
+	}
+
+	if int64(len(data)) > sizeLimit {
+		return data[:sizeLimit], true, nil
+	}
+
+	return data, false, nil
+}
+
 func ToString(width int, img image.Image) string {
 	img = imaging.Resize(img, width, 0, imaging.Lanczos)
 	b := img.Bounds()
@@ -75,6 +94,21 @@ func ImagePreview(width int, filename string) (string, error) {
 	return imageString, nil
 }
 
+// DecodeDataURL decodes an image sent as a base64 data URL, like the file
+// parts of a message.
+func DecodeDataURL(url string) (image.Image, error) {
+	header, data, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasPrefix(url, "data:") || !strings.HasSuffix(header, ";base64") {
+		return nil, fmt.Errorf("not a base64 data URL")
+	}
+	content, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(bytes.NewReader(content))
+	return img, err
+}
+
 func ImageToBytes(image image.Image) ([]byte, error) {
 	buf := new(bytes.Buffer)
 	err := png.Encode(buf, image)
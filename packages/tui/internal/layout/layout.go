@@ -34,6 +34,12 @@ type Modal interface {
 	Close() tea.Cmd
 }
 
+// EscapeHandler is implemented by modals that use esc themselves while
+// Escapes reports true, such as to cancel an edit, rather than being closed
+type EscapeHandler interface {
+	Escapes() bool
+}
+
 type Focusable interface {
 	Focus() tea.Cmd
 	Blur() tea.Cmd
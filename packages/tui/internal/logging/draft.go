@@ -0,0 +1,69 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const draftFile = "draft.json"
+
+// Draft is the unsent editor content saved when the TUI crashes.
+type Draft struct {
+	SessionID string    `json:"sessionID,omitempty"`
+	Text      string    `json:"text"`
+	Time      time.Time `json:"time"`
+}
+
+func draftPath() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, draftFile)
+}
+
+// SaveDraft writes the current draft to disk. Empty drafts are not saved.
+func SaveDraft() error {
+	mu.Lock()
+	current := draft
+	mu.Unlock()
+
+	path := draftPath()
+	if path == "" || current.Text == "" {
+		return nil
+	}
+	current.Time = time.Now()
+
+	data, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadDraft returns the draft saved by a previous crash, if any, and removes
+// it from disk so it is only restored once.
+func LoadDraft() (*Draft, error) {
+	path := draftPath()
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	os.Remove(path)
+
+	var saved Draft
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
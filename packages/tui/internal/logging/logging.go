@@ -0,0 +1,111 @@
+// Package logging keeps recent log output in memory and recovers from panics
+// by writing a crash report and saving the user's draft.
+package logging
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"sync"
+)
+
+const recentLineCount = 200
+
+// Level is the minimum level logged by handlers created with NewHandler.
+// It can be changed while the TUI is running.
+var Level = new(slog.LevelVar)
+
+// NewHandler returns a text handler, or a JSON handler if format is "json",
+// that logs at Level.
+func NewHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: Level}
+	if format == "json" {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// ParseLevel parses a level name such as "debug", "info", "warn" or "error".
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(name))
+	return level, err
+}
+
+var (
+	mu         sync.Mutex
+	dataDir    string
+	appVersion string
+	draft      Draft
+	lastReport string
+)
+
+// Init sets the directory crash reports and drafts are written to and the
+// version recorded in crash reports.
+func Init(dir string, version string) {
+	mu.Lock()
+	defer mu.Unlock()
+	dataDir = dir
+	appVersion = version
+}
+
+// SetDraft records the current draft, to be saved if a panic is recovered.
+// The UI calls it after every update, so a panic on another goroutine never
+// has to read the editor.
+func SetDraft(d Draft) {
+	mu.Lock()
+	defer mu.Unlock()
+	draft = d
+}
+
+// LastCrashReport returns the path of the last crash report written by this
+// process, or an empty string if there was none.
+func LastCrashReport() string {
+	mu.Lock()
+	defer mu.Unlock()
+	return lastReport
+}
+
+func logDir() string {
+	mu.Lock()
+	defer mu.Unlock()
+	if dataDir == "" {
+		return ""
+	}
+	return filepath.Join(dataDir, "log")
+}
+
+// Recent is a writer that keeps the most recent log lines in memory so they
+// can be included in crash reports. Tee the log output into it.
+var Recent = &ringWriter{lines: make([]string, recentLineCount)}
+
+type ringWriter struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	count int
+}
+
+func (w *ringWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		w.lines[w.next] = string(line)
+		w.next = (w.next + 1) % len(w.lines)
+		w.count = min(w.count+1, len(w.lines))
+	}
+	return len(p), nil
+}
+
+// Lines returns the buffered lines, oldest first.
+func (w *ringWriter) Lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := make([]string, 0, w.count)
+	start := (w.next - w.count + len(w.lines)) % len(w.lines)
+	for i := range w.count {
+		lines = append(lines, w.lines[(start+i)%len(w.lines)])
+	}
+	return lines
+}
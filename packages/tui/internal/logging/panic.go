@@ -0,0 +1,84 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// RecoverPanic recovers from a panic in the calling goroutine. It saves the
+// draft, writes a crash report and then runs cleanup, if any. It must be
+// called directly by a deferred function:
+//
+//	defer logging.RecoverPanic("subscription", nil)
+func RecoverPanic(name string, cleanup func()) {
+	if r := recover(); r != nil {
+		ReportPanic(name, r, debug.Stack())
+		if cleanup != nil {
+			cleanup()
+		}
+	}
+}
+
+// ReportPanic saves the draft and writes a crash report for a recovered
+// panic. It returns the path of the report, or an empty string if it could
+// not be written.
+func ReportPanic(name string, r any, stack []byte) string {
+	slog.Error("Panic recovered", "name", name, "panic", r)
+
+	if err := SaveDraft(); err != nil {
+		slog.Error("Failed to save draft", "error", err)
+	}
+
+	path, err := writeCrashReport(name, r, stack)
+	if err != nil {
+		slog.Error("Failed to write crash report", "error", err)
+		return ""
+	}
+	slog.Info("Crash report written", "path", path)
+
+	mu.Lock()
+	lastReport = path
+	mu.Unlock()
+	return path
+}
+
+func writeCrashReport(name string, r any, stack []byte) (string, error) {
+	dir := logDir()
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s.log", now.Format("20060102-150405")))
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	mu.Lock()
+	version := appVersion
+	mu.Unlock()
+
+	fmt.Fprintf(file, "Panic in %s: %v\n\n", name, r)
+	fmt.Fprintf(file, "Time: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(file, "Version: %s\n", version)
+	fmt.Fprintf(file, "Platform: %s/%s %s\n\n", runtime.GOOS, runtime.GOARCH, runtime.Version())
+	if len(stack) > 0 {
+		fmt.Fprintf(file, "Stack Trace:\n%s\n", stack)
+	} else {
+		fmt.Fprintf(file, "Stack Trace: unavailable\n\n")
+	}
+	fmt.Fprintf(file, "Recent Logs:\n%s\n", strings.Join(Recent.Lines(), "\n"))
+
+	return path, nil
+}
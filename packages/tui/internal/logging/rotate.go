@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RotateOptions controls when a RotatingFile starts a new file and how many
+// old ones it keeps.
+type RotateOptions struct {
+	// MaxSize rotates the file once it would grow past this many bytes.
+	// Zero disables rotation.
+	MaxSize int64
+	// MaxBackups is the number of rotated files to keep, named like
+	// tui.1.log (newest) to tui.N.log (oldest).
+	MaxBackups int
+}
+
+var DefaultRotation = RotateOptions{
+	MaxSize:    10 * 1024 * 1024, // 10MB
+	MaxBackups: 5,
+}
+
+// RotatingFile is a log file that moves itself aside when it gets too big.
+// It is appended to rather than replaced when opened, as other instances may
+// be writing to the same file.
+type RotatingFile struct {
+	mu   sync.Mutex
+	path string
+	opts RotateOptions
+	file *os.File
+	size int64
+}
+
+func OpenRotatingFile(path string, opts RotateOptions) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, opts: opts}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *RotatingFile) shouldRotate(n int) bool {
+	return f.opts.MaxSize > 0 && f.size > 0 && f.size+int64(n) > f.opts.MaxSize
+}
+
+// open appends to the file at path, creating it if needed
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file = file
+	f.size = info.Size()
+	return nil
+}
+
+// rotate moves the file aside and starts a new one. If another instance has
+// rotated it already, the new file is just opened.
+func (f *RotatingFile) rotate() error {
+	current, err := f.file.Stat()
+	f.file.Close()
+	f.file = nil
+
+	if info, statErr := os.Stat(f.path); err == nil && statErr == nil && os.SameFile(current, info) {
+		if err := f.shiftBackups(); err != nil {
+			return err
+		}
+	}
+	return f.open()
+}
+
+// shiftBackups renames tui.log to tui.1.log, tui.1.log to tui.2.log and so
+// on, dropping the oldest backup.
+func (f *RotatingFile) shiftBackups() error {
+	if f.opts.MaxBackups <= 0 {
+		return os.Remove(f.path)
+	}
+	os.Remove(f.backupPath(f.opts.MaxBackups))
+	for i := f.opts.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(f.path, f.backupPath(1))
+}
+
+func (f *RotatingFile) backupPath(i int) string {
+	ext := filepath.Ext(f.path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(f.path, ext), i, ext)
+}
@@ -0,0 +1,175 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RotateOptions
+		existing string
+		writes   []string
+		// want maps the file names in the directory to their content
+		want map[string]string
+	}{
+		{
+			name:   "under the limit",
+			opts:   RotateOptions{MaxSize: 10, MaxBackups: 2},
+			writes: []string{"aaa", "bbb"},
+			want:   map[string]string{"tui.log": "aaabbb"},
+		},
+		{
+			name:   "rotates before a write that would pass the limit",
+			opts:   RotateOptions{MaxSize: 10, MaxBackups: 2},
+			writes: []string{"aaaaaa", "bbbbbb"},
+			want:   map[string]string{"tui.log": "bbbbbb", "tui.1.log": "aaaaaa"},
+		},
+		{
+			name:   "write up to the limit stays",
+			opts:   RotateOptions{MaxSize: 10, MaxBackups: 2},
+			writes: []string{"aaaaa", "bbbbb"},
+			want:   map[string]string{"tui.log": "aaaaabbbbb"},
+		},
+		{
+			name:   "backups shift and the oldest is dropped",
+			opts:   RotateOptions{MaxSize: 4, MaxBackups: 2},
+			writes: []string{"aaaa", "bbbb", "cccc", "dddd"},
+			want:   map[string]string{"tui.log": "dddd", "tui.1.log": "cccc", "tui.2.log": "bbbb"},
+		},
+		{
+			name:   "no backups removes the file",
+			opts:   RotateOptions{MaxSize: 4, MaxBackups: 0},
+			writes: []string{"aaaa", "bbbb"},
+			want:   map[string]string{"tui.log": "bbbb"},
+		},
+		{
+			name:   "write larger than the limit goes into an empty file",
+			opts:   RotateOptions{MaxSize: 4, MaxBackups: 2},
+			writes: []string{"aaaaaaaa", "bb"},
+			want:   map[string]string{"tui.log": "bb", "tui.1.log": "aaaaaaaa"},
+		},
+		{
+			name:   "zero size disables rotation",
+			opts:   RotateOptions{MaxSize: 0, MaxBackups: 2},
+			writes: []string{"aaaa", "bbbb", "cccc"},
+			want:   map[string]string{"tui.log": "aaaabbbbcccc"},
+		},
+		{
+			name:     "existing file is appended to and counted",
+			opts:     RotateOptions{MaxSize: 10, MaxBackups: 2},
+			existing: "old",
+			writes:   []string{"aaaa", "bbbb"},
+			want:     map[string]string{"tui.log": "bbbb", "tui.1.log": "oldaaaa"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "tui.log")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := OpenRotatingFile(path, tt.opts)
+			if err != nil {
+				t.Fatalf("OpenRotatingFile() error = %v", err)
+			}
+			for _, write := range tt.writes {
+				if n, err := f.Write([]byte(write)); err != nil || n != len(write) {
+					t.Fatalf("Write(%q) = %d, %v", write, n, err)
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			assertFiles(t, dir, tt.want)
+		})
+	}
+}
+
+// TestRotatingFileRotatedElsewhere covers two instances writing to the same
+// file: the one rotating second only reopens the file the first one started.
+func TestRotatingFileRotatedElsewhere(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tui.log")
+	opts := RotateOptions{MaxSize: 4, MaxBackups: 2}
+
+	first, err := OpenRotatingFile(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := OpenRotatingFile(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer second.Close()
+
+	writes := []struct {
+		file *RotatingFile
+		data string
+	}{
+		{first, "aaaa"},
+		{second, "bb"},
+		// first rotates and starts a new file
+		{first, "cc"},
+		// second would rotate too, but the file it has open was moved
+		{second, "dddd"},
+	}
+	for _, write := range writes {
+		if _, err := write.file.Write([]byte(write.data)); err != nil {
+			t.Fatalf("Write(%q) error = %v", write.data, err)
+		}
+	}
+
+	assertFiles(t, dir, map[string]string{"tui.log": "ccdddd", "tui.1.log": "aaaabb"})
+}
+
+func TestRotatingFileWriteAfterClose(t *testing.T) {
+	f, err := OpenRotatingFile(filepath.Join(t.TempDir(), "tui.log"), DefaultRotation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close() error = %v", err)
+	}
+	if _, err := f.Write([]byte("a")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() error = %v, want os.ErrClosed", err)
+	}
+}
+
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(want) {
+		names := []string{}
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		t.Errorf("files = %v, want %d files", names, len(want))
+	}
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if string(got) != content {
+			t.Errorf("%s = %q, want %q", name, got, content)
+		}
+	}
+}
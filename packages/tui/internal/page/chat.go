@@ -29,6 +29,12 @@ type chatPage struct {
 type ChatKeyMap struct {
 	Cancel               key.Binding
 	ToggleTools          key.Binding
+	ToggleThinking       key.Binding
+	SelectPreviousTool   key.Binding
+	SelectNextTool       key.Binding
+	ExpandTool           key.Binding
+	CollapseTool         key.Binding
+	ExpandAllTool        key.Binding
 	ShowCompletionDialog key.Binding
 }
 
@@ -41,6 +47,30 @@ var keyMap = ChatKeyMap{
 		key.WithKeys("ctrl+h"),
 		key.WithHelp("ctrl+h", "toggle tools"),
 	),
+	ToggleThinking: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "expand thinking"),
+	),
+	SelectPreviousTool: key.NewBinding(
+		key.WithKeys("alt+up"),
+		key.WithHelp("alt+up", "select previous tool"),
+	),
+	SelectNextTool: key.NewBinding(
+		key.WithKeys("alt+down"),
+		key.WithHelp("alt+down", "select next tool"),
+	),
+	ExpandTool: key.NewBinding(
+		key.WithKeys("alt+enter"),
+		key.WithHelp("alt+enter", "expand tool"),
+	),
+	CollapseTool: key.NewBinding(
+		key.WithKeys("alt+x"),
+		key.WithHelp("alt+x", "collapse tool"),
+	),
+	ExpandAllTool: key.NewBinding(
+		key.WithKeys("alt+e"),
+		key.WithHelp("alt+e", "expand all of the tool"),
+	),
 	ShowCompletionDialog: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "Complete"),
@@ -67,6 +97,8 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd != nil {
 			return p, cmd
 		}
+	case dialog.FixDiagnosticsMsg:
+		return p, p.sendMessage(msg.Prompt, nil)
 	case dialog.CompletionDialogCloseMsg:
 		p.showCompletionDialog = false
 	case tea.KeyMsg:
@@ -91,6 +123,18 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		case key.Matches(msg, keyMap.ToggleTools):
 			return p, util.CmdHandler(chat.ToggleToolMessagesMsg{})
+		case key.Matches(msg, keyMap.ToggleThinking):
+			return p, util.CmdHandler(chat.ToggleThinkingMsg{})
+		case key.Matches(msg, keyMap.SelectPreviousTool):
+			return p, util.CmdHandler(chat.SelectToolMsg{Delta: -1})
+		case key.Matches(msg, keyMap.SelectNextTool):
+			return p, util.CmdHandler(chat.SelectToolMsg{Delta: 1})
+		case key.Matches(msg, keyMap.ExpandTool):
+			return p, util.CmdHandler(chat.ExpandToolMsg{})
+		case key.Matches(msg, keyMap.CollapseTool):
+			return p, util.CmdHandler(chat.CollapseToolMsg{})
+		case key.Matches(msg, keyMap.ExpandAllTool):
+			return p, util.CmdHandler(chat.ExpandToolMsg{All: true})
 		}
 	}
 
@@ -122,6 +166,10 @@ func (p *chatPage) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (p *chatPage) sendMessage(text string, attachments []app.Attachment) tea.Cmd {
 	var cmds []tea.Cmd
+	if p.app.IsBusy() {
+		// hold the prompt until the current turn has finished
+		return p.app.Enqueue(p.app.Session.Id, text, attachments)
+	}
 	cmd := p.app.SendChatMessage(context.Background(), text, attachments)
 	cmds = append(cmds, cmd)
 	return tea.Batch(cmds...)
@@ -135,6 +183,12 @@ func (p *chatPage) GetSize() (int, int) {
 	return p.layout.GetSize()
 }
 
+// EditorValue returns the text currently in the editor.
+func (p *chatPage) EditorValue() string {
+	editor := p.editor.GetContent().(interface{ GetValue() string })
+	return editor.GetValue()
+}
+
 func (p *chatPage) View() string {
 	layoutView := p.layout.View()
 
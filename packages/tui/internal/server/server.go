@@ -0,0 +1,326 @@
+// Package server starts and supervises a local opencode server for when the
+// TUI is launched without OPENCODE_SERVER.
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sst/opencode/internal/logging"
+	"github.com/sst/opencode/pkg/client"
+)
+
+const (
+	healthTimeout   = 15 * time.Second
+	healthInterval  = 100 * time.Millisecond
+	stopTimeout     = 5 * time.Second
+	initialBackoff  = 500 * time.Millisecond
+	maxBackoff      = 30 * time.Second
+	stableUptime    = time.Minute
+	maxBufferedLogs = 1024 * 1024
+)
+
+// Supervisor runs an opencode server as a child process and restarts it
+// if it dies.
+type Supervisor struct {
+	bin string
+	// socket is the Unix socket the server listens on, port is used where
+	// there are none
+	socket string
+	port   int
+	url    string
+	log    *logWriter
+
+	mu       sync.Mutex
+	cmd      *exec.Cmd
+	exited   chan struct{}
+	stopping bool
+	done     chan struct{}
+}
+
+// Start launches the server binary, named by OPENCODE_BIN or found as
+// "opencode" in PATH, and waits for it to be healthy. It listens on a Unix
+// socket in a private temporary directory, or on a free local port on
+// Windows.
+func Start(ctx context.Context) (*Supervisor, error) {
+	bin := os.Getenv("OPENCODE_BIN")
+	if bin == "" {
+		path, err := exec.LookPath("opencode")
+		if err != nil {
+			return nil, fmt.Errorf("no server configured and opencode was not found in PATH; set OPENCODE_SERVER or OPENCODE_BIN")
+		}
+		bin = path
+	}
+
+	s := &Supervisor{
+		bin:  bin,
+		log:  &logWriter{},
+		done: make(chan struct{}),
+	}
+	if runtime.GOOS == "windows" {
+		// another process may take the port before the server binds it,
+		// the server then fails to start and Start reports it
+		port, err := freePort()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find a free port: %w", err)
+		}
+		s.port = port
+		s.url = fmt.Sprintf("http://127.0.0.1:%d/", port)
+	} else {
+		dir, err := os.MkdirTemp("", "opencode-")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the server socket directory: %w", err)
+		}
+		s.socket = filepath.Join(dir, "server.sock")
+		s.url = "unix://" + s.socket
+	}
+
+	if err := s.spawn(); err != nil {
+		s.removeSocket()
+		return nil, err
+	}
+	if err := s.waitHealthy(ctx); err != nil {
+		s.Stop()
+		return nil, err
+	}
+
+	go s.supervise()
+	return s, nil
+}
+
+// URL is the address the server listens on, an http:// or unix:// URL to
+// pass to client.TransportConfig. It stays the same across restarts.
+func (s *Supervisor) URL() string {
+	return s.url
+}
+
+// SetLogFile writes the server's output to path, including anything it
+// printed before the file was known.
+func (s *Supervisor) SetLogFile(path string) error {
+	file, err := logging.OpenRotatingFile(path, logging.DefaultRotation)
+	if err != nil {
+		return err
+	}
+	return s.log.SetOutput(file)
+}
+
+// Stop terminates the server and stops restarting it. It is safe to call
+// on a nil Supervisor and more than once.
+func (s *Supervisor) Stop() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return
+	}
+	s.stopping = true
+	close(s.done)
+	cmd, exited := s.cmd, s.exited
+	s.mu.Unlock()
+
+	if cmd != nil && cmd.Process != nil {
+		// ask nicely first; interrupts are not supported on windows
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			cmd.Process.Kill()
+		}
+		select {
+		case <-exited:
+		case <-time.After(stopTimeout):
+			slog.Warn("opencode server did not exit, killing it")
+			cmd.Process.Kill()
+			<-exited
+		}
+	}
+	s.removeSocket()
+	s.log.Close()
+}
+
+// removeSocket removes the directory of the server socket
+func (s *Supervisor) removeSocket() {
+	if s.socket != "" {
+		os.RemoveAll(filepath.Dir(s.socket))
+	}
+}
+
+func (s *Supervisor) spawn() error {
+	args := []string{"serve", "--port", strconv.Itoa(s.port), "--hostname", "127.0.0.1"}
+	if s.socket != "" {
+		// a server that died leaves its socket behind
+		os.Remove(s.socket)
+		args = []string{"serve", "--socket", s.socket}
+	}
+	cmd := exec.Command(s.bin, args...)
+	cmd.Stdout = s.log
+	cmd.Stderr = s.log
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start opencode server: %w", err)
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		err := cmd.Wait()
+		slog.Debug("opencode server exited", "pid", cmd.Process.Pid, "error", err)
+		close(exited)
+	}()
+
+	s.mu.Lock()
+	s.cmd = cmd
+	s.exited = exited
+	s.mu.Unlock()
+	return nil
+}
+
+// supervise restarts the server whenever it exits, backing off if it keeps
+// dying shortly after starting.
+func (s *Supervisor) supervise() {
+	backoff := initialBackoff
+	for {
+		s.mu.Lock()
+		exited := s.exited
+		s.mu.Unlock()
+
+		started := time.Now()
+		select {
+		case <-exited:
+		case <-s.done:
+			return
+		}
+		if time.Since(started) > stableUptime {
+			backoff = initialBackoff
+		}
+
+		slog.Warn("opencode server exited unexpectedly, restarting", "backoff", backoff)
+		select {
+		case <-time.After(backoff):
+		case <-s.done:
+			return
+		}
+		backoff = min(backoff*2, maxBackoff)
+
+		s.mu.Lock()
+		if s.stopping {
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		if err := s.spawn(); err != nil {
+			slog.Error("Failed to restart opencode server", "error", err)
+			// retry on the next iteration
+			s.mu.Lock()
+			s.exited = closedChan()
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *Supervisor) waitHealthy(ctx context.Context) error {
+	transport := client.TransportConfig{Server: s.url}
+	opts, err := transport.Options()
+	if err != nil {
+		return err
+	}
+	httpClient, err := client.NewClientWithResponses(transport.ServerURL(), opts...)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthTimeout)
+	defer cancel()
+	for {
+		response, err := httpClient.PostAppInfoWithResponse(ctx)
+		if err == nil && response.StatusCode() == 200 {
+			return nil
+		}
+
+		s.mu.Lock()
+		exited := s.exited
+		s.mu.Unlock()
+		select {
+		case <-exited:
+			return fmt.Errorf("opencode server exited during startup:\n%s", s.log.Buffered())
+		case <-ctx.Done():
+			return fmt.Errorf("opencode server did not become healthy within %s", healthTimeout)
+		case <-time.After(healthInterval):
+		}
+	}
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+func closedChan() chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// logWriter buffers output in memory until a destination is set.
+type logWriter struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+	out io.WriteCloser
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out != nil {
+		return w.out.Write(p)
+	}
+	// keep the most recent output if the server is chatty before the log
+	// file is known
+	if w.buf.Len()+len(p) > maxBufferedLogs {
+		w.buf.Reset()
+	}
+	return w.buf.Write(p)
+}
+
+func (w *logWriter) SetOutput(out io.WriteCloser) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out != nil {
+		return errors.New("log output already set")
+	}
+	if _, err := w.buf.WriteTo(out); err != nil {
+		return err
+	}
+	w.out = out
+	return nil
+}
+
+// Buffered returns output that has not been written to a log file yet.
+func (w *logWriter) Buffered() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func (w *logWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil {
+		return nil
+	}
+	return w.out.Close()
+}
@@ -11,8 +11,30 @@ type ModelSelectedMsg struct {
 }
 
 type SessionClearedMsg struct{}
+
+// PromptMsg fills the editor with Text, sending it right away if Send is set.
+type PromptMsg struct {
+	Text string
+	Send bool
+}
+
+// QueueUpdatedMsg is sent when prompts are added to or removed from the
+// outbox of a session.
+type QueueUpdatedMsg struct {
+	SessionID string
+}
+
 type CompactSessionMsg struct{}
 
+// ShowThinkingChangedMsg is sent when the show_thinking setting is toggled.
+type ShowThinkingChangedMsg struct{}
+
+// MessageUpdatedMsg is sent when a message of the current session changes,
+// so only that message is rendered again.
+type MessageUpdatedMsg struct {
+	MessageID string
+}
+
 // TODO: remove
 type StateUpdatedMsg struct {
 	State map[string]any
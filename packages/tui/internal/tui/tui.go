@@ -3,6 +3,7 @@ package tui
 import (
 	"context"
 	"log/slog"
+	"runtime/debug"
 
 	"github.com/charmbracelet/bubbles/v2/cursor"
 	"github.com/charmbracelet/bubbles/v2/key"
@@ -16,8 +17,11 @@ import (
 	"github.com/sst/opencode/internal/components/dialog"
 	"github.com/sst/opencode/internal/components/modal"
 	"github.com/sst/opencode/internal/layout"
+	"github.com/sst/opencode/internal/logging"
 	"github.com/sst/opencode/internal/page"
+	"github.com/sst/opencode/internal/pubsub"
 	"github.com/sst/opencode/internal/state"
+	"github.com/sst/opencode/internal/status"
 	"github.com/sst/opencode/internal/styles"
 	"github.com/sst/opencode/internal/theme"
 	"github.com/sst/opencode/internal/util"
@@ -33,6 +37,9 @@ type appModel struct {
 	status        core.StatusComponent
 	app           *app.App
 	modal         layout.Modal
+	// crashed is set once a panic has been recovered, after which the
+	// program quits on the next update
+	crashed *bool
 }
 
 func (a appModel) Init() tea.Cmd {
@@ -48,6 +55,9 @@ func (a appModel) Init() tea.Cmd {
 	cmd = a.status.Init()
 	cmds = append(cmds, cmd)
 
+	// apply the command line options, such as --session and --prompt
+	cmds = append(cmds, a.app.Startup())
+
 	// Check if we should show the init dialog
 	cmds = append(cmds, func() tea.Msg {
 		shouldShow := app.Info.Git && app.Info.Time.Initialized == nil
@@ -74,23 +84,53 @@ func (a appModel) updateAllPages(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
-func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+func (a appModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	if *a.crashed {
+		return a, tea.Quit
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			*a.crashed = true
+			logging.ReportPanic("update", r, debug.Stack())
+			model, cmd = a, tea.Quit
+		}
+	}()
+	model, cmd = a.update(msg)
+	a.snapshotDraft()
+	return model, cmd
+}
+
+// snapshotDraft records the editor content for the crash handler, which may
+// run on another goroutine and can't read the editor itself
+func (a appModel) snapshotDraft() {
+	draft := logging.Draft{SessionID: a.app.Session.Id}
+	if editor, ok := a.pages[page.ChatPage].(interface{ EditorValue() string }); ok {
+		draft.Text = editor.EditorValue()
+	}
+	logging.SetDraft(draft)
+}
+
+func (a appModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 	var cmd tea.Cmd
 
 	if a.modal != nil {
 		bypassModal := false
 
+		// closing a dialog brings back any pending permission request, which
+		// has to be answered
 		if _, ok := msg.(modal.CloseModalMsg); ok {
-			a.modal = nil
+			a.modal = a.permissionDialog()
 			return a, nil
 		}
 
 		if msg, ok := msg.(tea.KeyMsg); ok {
 			switch msg.String() {
 			case "esc":
-				a.modal = nil
-				return a, nil
+				if handler, ok := a.modal.(layout.EscapeHandler); !ok || !handler.Escapes() {
+					a.modal = a.permissionDialog()
+					return a, nil
+				}
 			case "ctrl+c":
 				return a, tea.Quit
 			}
@@ -110,7 +150,19 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			bypassModal = true
 		case client.EventSessionUpdated:
 			bypassModal = true
-		case client.EventMessageUpdated:
+		case client.EventMessageUpdated, client.EventMessagePartUpdated:
+			bypassModal = true
+		case client.EventDisconnected, client.EventReconnected, sessionResyncedMsg, sessionSharedMsg:
+			bypassModal = true
+		case state.SessionSelectedMsg, state.PromptMsg, state.QueueUpdatedMsg, state.ShowThinkingChangedMsg, app.SessionModelMsg, app.CompactFailedMsg:
+			bypassModal = true
+		case pubsub.Event[status.StatusMessage]:
+			bypassModal = true
+		case client.EventPermissionUpdated, dialog.PermissionResponseMsg:
+			bypassModal = true
+		case client.EventSessionError, dialog.RetryPromptMsg:
+			bypassModal = true
+		case client.EventLspClientDiagnostics:
 			bypassModal = true
 		case cursor.BlinkMsg:
 			bypassModal = true
@@ -140,6 +192,40 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "model":
 			modelDialog := dialog.NewModelDialog(a.app)
 			a.modal = modelDialog
+		case "queue":
+			queueDialog := dialog.NewQueueDialog(a.app)
+			a.modal = queueDialog
+		case "diagnostics":
+			if dialog.IsDiagnosticsDialog(a.modal) {
+				a.modal = a.permissionDialog()
+				break
+			}
+			a.modal = dialog.NewDiagnosticsDialog(a.app)
+		case "thinking":
+			a.app.Config.ShowThinking = !a.app.Config.ShowThinking
+			a.app.SaveConfig()
+			if a.app.Config.ShowThinking {
+				status.Info("Showing thinking")
+			} else {
+				status.Info("Hiding thinking")
+			}
+			cmds = append(cmds, util.CmdHandler(state.ShowThinkingChangedMsg{}))
+		case "compact":
+			cmds = append(cmds, util.CmdHandler(state.CompactSessionMsg{}))
+		case "permissions":
+			a.modal = dialog.NewPermissionRulesDialog(a.app)
+		case "share":
+			if a.app.Session.Id == "" {
+				status.Warn("Send a message before sharing the session")
+				break
+			}
+			cmds = append(cmds, a.shareSession(true))
+		case "unshare":
+			if a.app.Session.Share == nil {
+				status.Info("This session is not shared")
+				break
+			}
+			cmds = append(cmds, a.shareSession(false))
 		case "theme":
 			themeDialog := dialog.NewThemeDialog()
 			a.modal = themeDialog
@@ -176,14 +262,61 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case client.EventMessageUpdated:
+		// compact the session or send the next queued prompt once a turn has
+		// finished, in any session this app prompted
+		cmds = append(cmds, a.app.TurnCompleted(context.Background(), msg.Properties.Info))
 		if msg.Properties.Info.Metadata.SessionID == a.app.Session.Id {
-			for i, m := range a.app.Messages {
-				if m.Id == msg.Properties.Info.Id {
-					a.app.Messages[i] = msg.Properties.Info
-					return a.updateAllPages(state.StateUpdatedMsg{State: nil})
-				}
-			}
-			a.app.Messages = append(a.app.Messages, msg.Properties.Info)
+			a.app.UpdateMessage(msg.Properties.Info)
+			model, cmd := a.updateAllPages(state.MessageUpdatedMsg{MessageID: msg.Properties.Info.Id})
+			return model, tea.Batch(append(cmds, cmd)...)
+		}
+
+	case client.EventMessagePartUpdated:
+		// parts of messages that aren't loaded yet come with the next
+		// message.updated event
+		if msg.Properties.SessionID == a.app.Session.Id &&
+			a.app.UpdateMessagePart(msg.Properties.MessageID, msg.Properties.Part) {
+			return a.updateAllPages(state.MessageUpdatedMsg{MessageID: msg.Properties.MessageID})
+		}
+		return a, nil
+
+	case client.EventReconnected:
+		// events published while the stream was down are lost, so reload
+		// the current session from the server
+		if a.app.Session.Id != "" {
+			cmds = append(cmds, a.resyncSession(a.app.Session.Id))
+		}
+
+	case state.CompactSessionMsg:
+		if a.app.Session.Id == "" {
+			status.Warn("There is no session to compact")
+			return a, nil
+		}
+		if a.app.IsBusy() {
+			status.Warn("Agent is working, compact the session once it has finished")
+			return a, nil
+		}
+		return a, a.app.CompactSession(context.Background(), a.app.Session.Id)
+
+	case app.CompactFailedMsg:
+		return a, a.app.CompactFailed(context.Background(), msg.SessionID)
+
+	case sessionSharedMsg:
+		if msg.session.Id != a.app.Session.Id {
+			return a, nil
+		}
+		a.app.Session = msg.session
+		if msg.session.Share != nil {
+			dialog.CopyToClipboard(msg.session.Share.Url)
+			a.modal = dialog.NewShareDialog(msg.session.Share.Url)
+		} else {
+			status.Info("Session is no longer shared")
+		}
+		return a.updateAllPages(state.StateUpdatedMsg{State: nil})
+
+	case sessionResyncedMsg:
+		if msg.sessionID == a.app.Session.Id {
+			a.app.Messages = msg.messages
 			return a.updateAllPages(state.StateUpdatedMsg{State: nil})
 		}
 
@@ -224,22 +357,52 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		return a, tea.Batch(cmds...)
 
-	// case pubsub.Event[permission.PermissionRequest]:
-	// 	a.showPermissions = true
-	// 	return a, a.permissions.SetPermissions(msg.Payload)
+	case client.EventPermissionUpdated:
+		if a.app.AnswerByRule(context.Background(), msg.Properties) {
+			return a, nil
+		}
+		a.app.AddPermission(msg.Properties)
+		if _, ok := a.modal.(dialog.PermissionDialog); !ok {
+			a.modal = a.permissionDialog()
+		}
+		return a, nil
 
 	case dialog.PermissionResponseMsg:
-		// TODO: Permissions service not implemented in API yet
-		// var cmd tea.Cmd
-		// switch msg.Action {
-		// case dialog.PermissionAllow:
-		// 	a.app.Permissions.Grant(context.Background(), msg.Permission)
-		// case dialog.PermissionAllowForSession:
-		// 	a.app.Permissions.GrantPersistant(context.Background(), msg.Permission)
-		// case dialog.PermissionDeny:
-		// 	a.app.Permissions.Deny(context.Background(), msg.Permission)
-		// }
-		// a.showPermissions = false
+		a.app.RespondPermission(context.Background(), msg.Permission, msg.Action.Response())
+		a.modal = a.permissionDialog()
+		return a, nil
+
+	case client.EventLspClientDiagnostics:
+		a.app.UpdateDiagnostics(msg.Properties.ServerID, msg.Properties.Path, msg.Properties.Diagnostics)
+		if dialog.IsDiagnosticsDialog(a.modal) {
+			updated, cmd := a.modal.Update(msg)
+			a.modal = updated.(layout.Modal)
+			return a, cmd
+		}
+		return a, nil
+
+	case client.EventSessionError:
+		if msg.Properties.Error == nil || msg.Properties.SessionID != a.app.Session.Id {
+			return a, nil
+		}
+		// a permission request has to be answered first, the error is
+		// still shown in the transcript
+		if _, ok := a.modal.(dialog.PermissionDialog); ok {
+			status.Error("The last prompt failed")
+			return a, nil
+		}
+		errorDialog := dialog.NewSessionErrorDialog(a.app, *msg.Properties.Error)
+		a.modal = errorDialog
+		return a, errorDialog.Init()
+
+	case dialog.RetryPromptMsg:
+		if a.app.IsBusy() {
+			status.Warn("Agent is working, retry once it has finished")
+			return a, nil
+		}
+		if err := a.app.RetryLastPrompt(context.Background()); err != nil {
+			status.Warn(err.Error())
+		}
 		return a, nil
 
 	case page.PageChangeMsg:
@@ -248,7 +411,13 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case state.SessionSelectedMsg:
 		a.app.Session = msg
 		a.app.Messages, _ = a.app.ListMessages(context.Background(), msg.Id)
-		return a.updateAllPages(msg)
+		restore := a.app.RestoreSessionModel(context.Background())
+		model, cmd := a.updateAllPages(msg)
+		return model, tea.Batch(cmd, restore)
+
+	case app.SessionModelMsg:
+		a.app.ApplySessionModel(msg)
+		return a, nil
 
 	case state.ModelSelectedMsg:
 		a.app.Provider = &msg.Provider
@@ -311,6 +480,55 @@ func (a appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, tea.Batch(cmds...)
 }
 
+type sessionResyncedMsg struct {
+	sessionID string
+	messages  []client.MessageInfo
+}
+
+func (a appModel) resyncSession(sessionID string) tea.Cmd {
+	return func() tea.Msg {
+		messages, err := a.app.ListMessages(context.Background(), sessionID)
+		if err != nil {
+			slog.Error("Failed to resync session", "error", err)
+			return nil
+		}
+		return sessionResyncedMsg{sessionID: sessionID, messages: messages}
+	}
+}
+
+// permissionDialog returns a dialog for the oldest pending permission
+// request, or nil if there is none.
+func (a appModel) permissionDialog() layout.Modal {
+	if dialog := dialog.NewPermissionDialog(a.app); dialog != nil {
+		return dialog
+	}
+	return nil
+}
+
+type sessionSharedMsg struct {
+	session *client.SessionInfo
+}
+
+// shareSession shares or unshares the current session
+func (a appModel) shareSession(share bool) tea.Cmd {
+	sessionID := a.app.Session.Id
+	return func() tea.Msg {
+		var session *client.SessionInfo
+		var err error
+		if share {
+			session, err = a.app.ShareSession(context.Background(), sessionID)
+		} else {
+			session, err = a.app.UnshareSession(context.Background(), sessionID)
+		}
+		if err != nil {
+			slog.Error("Failed to update session sharing", "error", err)
+			status.Error(err.Error())
+			return nil
+		}
+		return sessionSharedMsg{session: session}
+	}
+}
+
 func (a *appModel) moveToPage(pageID page.PageID) tea.Cmd {
 	var cmds []tea.Cmd
 	if _, ok := a.loadedPages[pageID]; !ok {
@@ -328,7 +546,21 @@ func (a *appModel) moveToPage(pageID page.PageID) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
-func (a appModel) View() string {
+func (a appModel) View() (view string) {
+	if *a.crashed {
+		return ""
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			*a.crashed = true
+			logging.ReportPanic("view", r, debug.Stack())
+			view = ""
+		}
+	}()
+	return a.view()
+}
+
+func (a appModel) view() string {
 	components := []string{
 		a.pages[a.currentPage].View(),
 	}
@@ -344,14 +576,16 @@ func (a appModel) View() string {
 
 func NewModel(app *app.App) tea.Model {
 	startPage := page.ChatPage
+	chatPage := page.NewChatPage(app)
 	model := &appModel{
 		currentPage: startPage,
 		loadedPages: make(map[page.PageID]bool),
 		status:      core.NewStatusCmp(app),
 		app:         app,
 		pages: map[page.PageID]layout.ModelWithView{
-			page.ChatPage: page.NewChatPage(app),
+			page.ChatPage: chatPage,
 		},
+		crashed: new(bool),
 	}
 
 	return model
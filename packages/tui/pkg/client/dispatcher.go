@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// EventDispatcher routes events from the server's event stream to handlers
+// registered by event type. Handlers run sequentially on a single goroutine,
+// in the order events arrive.
+type EventDispatcher struct {
+	client *Client
+
+	mu       sync.RWMutex
+	handlers map[reflect.Type][]func(any)
+	all      []func(any)
+	errors   []func(error)
+}
+
+func NewEventDispatcher(c *Client) *EventDispatcher {
+	return &EventDispatcher{
+		client:   c,
+		handlers: make(map[reflect.Type][]func(any)),
+	}
+}
+
+// Handle registers fn to be called for every event of type T, for example
+// Handle(d, func(e EventMessageUpdated) { ... }).
+func Handle[T any](d *EventDispatcher, fn func(T)) {
+	t := reflect.TypeFor[T]()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handlers[t] = append(d.handlers[t], func(event any) {
+		fn(event.(T))
+	})
+}
+
+// HandleAll registers fn to be called for every event, regardless of type.
+func (d *EventDispatcher) HandleAll(fn func(any)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.all = append(d.all, fn)
+}
+
+// HandleError registers fn to be called for events that could not be decoded.
+func (d *EventDispatcher) HandleError(fn func(error)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errors = append(d.errors, fn)
+}
+
+// Start subscribes to the event stream and dispatches events until ctx is
+// cancelled. It returns an error if the initial subscription fails.
+func (d *EventDispatcher) Start(ctx context.Context) error {
+	stream, err := d.client.Event(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		events, errs := stream.Events, stream.Errors
+		for events != nil || errs != nil {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					events = nil
+					continue
+				}
+				d.dispatch(event)
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				d.dispatchError(err)
+			}
+		}
+	}()
+	return nil
+}
+
+func (d *EventDispatcher) dispatch(event any) {
+	d.mu.RLock()
+	handlers := d.handlers[reflect.TypeOf(event)]
+	all := d.all
+	d.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(event)
+	}
+	for _, fn := range all {
+		fn(event)
+	}
+}
+
+func (d *EventDispatcher) dispatchError(err error) {
+	d.mu.RLock()
+	handlers := d.errors
+	d.mu.RUnlock()
+
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
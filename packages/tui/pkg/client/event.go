@@ -1,54 +1,176 @@
 package client
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
-	"strings"
+	"time"
 )
 
-func (c *Client) Event(ctx context.Context) (<-chan any, error) {
-	events := make(chan any)
-	req, err := http.NewRequestWithContext(ctx, "GET", c.Server+"event", nil)
-	if err != nil {
-		return nil, err
-	}
+const (
+	eventInitialBackoff = 500 * time.Millisecond
+	eventMaxBackoff     = 30 * time.Second
+	eventErrorBuffer    = 16
+)
+
+// EventDisconnected is sent on the event channel when the stream drops.
+// The client keeps trying to reconnect until the context is cancelled.
+type EventDisconnected struct {
+	Error error
+}
+
+// EventReconnected is sent on the event channel once the stream has been
+// re-established. Events published while disconnected may have been missed,
+// so consumers should re-fetch any state they depend on.
+type EventReconnected struct{}
+
+// EventDecodeError is reported when an event payload cannot be decoded into
+// one of the known event types.
+type EventDecodeError struct {
+	ID    string
+	Event string
+	Data  string
+	Err   error
+}
+
+func (e *EventDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode event %q: %v", e.Data, e.Err)
+}
+
+func (e *EventDecodeError) Unwrap() error {
+	return e.Err
+}
 
-	resp, err := http.DefaultClient.Do(req)
+// EventStream is a subscription to the server's event stream. Events carries
+// the decoded event values (EventMessageUpdated, EventSessionError, ...) as
+// well as EventDisconnected and EventReconnected. Errors carries payloads
+// that could not be decoded; it is buffered and errors are dropped when
+// nobody reads them. Both channels are closed once the context is cancelled.
+type EventStream struct {
+	Events <-chan any
+	Errors <-chan error
+}
+
+func (c *Client) Event(ctx context.Context) (*EventStream, error) {
+	events := make(chan any)
+	errs := make(chan error, eventErrorBuffer)
+	resp, err := c.connectEvents(ctx, "")
 	if err != nil {
 		return nil, err
 	}
 
 	go func() {
+		defer close(errs)
 		defer close(events)
-		defer resp.Body.Close()
-
-		scanner := bufio.NewScanner(resp.Body)
-		scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
-		for scanner.Scan() {
-			line := scanner.Text()
-			if strings.HasPrefix(line, "data: ") {
-				data := strings.TrimPrefix(line, "data: ")
-
-				var event Event
-				if err := json.Unmarshal([]byte(data), &event); err != nil {
-					continue
-				}
 
-				val, err := event.ValueByDiscriminator()
-				if err != nil {
-					continue
-				}
+		lastEventID := ""
+		retry := eventInitialBackoff
+		for {
+			reader := newSSEReader(resp.Body, lastEventID)
+			err := readEvents(ctx, reader, events, errs)
+			resp.Body.Close()
+			lastEventID = reader.lastEventID
+			if reader.retry > 0 {
+				retry = reader.retry
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			if !sendEvent(ctx, events, EventDisconnected{Error: err}) {
+				return
+			}
 
+			backoff := retry
+			for {
 				select {
-				case events <- val:
+				case <-time.After(backoff):
 				case <-ctx.Done():
 					return
 				}
+				resp, err = c.connectEvents(ctx, lastEventID)
+				if err == nil {
+					break
+				}
+				backoff = min(backoff*2, eventMaxBackoff)
+			}
+			if !sendEvent(ctx, events, EventReconnected{}) {
+				return
 			}
 		}
 	}()
 
-	return events, nil
+	return &EventStream{Events: events, Errors: errs}, nil
+}
+
+func (c *Client) connectEvents(ctx context.Context, lastEventID string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.Server+"event", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	if err := c.applyEditors(ctx, req, nil); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to subscribe to events: %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+// readEvents decodes events until the stream ends. It always returns a
+// non-nil error describing why the stream stopped.
+func readEvents(ctx context.Context, reader *sseReader, events chan<- any, errs chan<- error) error {
+	for {
+		frame, err := reader.Next()
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(frame.Data), &event); err != nil {
+			reportError(errs, &EventDecodeError{ID: frame.ID, Event: frame.Event, Data: frame.Data, Err: err})
+			continue
+		}
+
+		// the server greets new subscribers with an empty payload
+		if discriminator, err := event.Discriminator(); err == nil && discriminator == "" {
+			continue
+		}
+
+		val, err := event.ValueByDiscriminator()
+		if err != nil {
+			reportError(errs, &EventDecodeError{ID: frame.ID, Event: frame.Event, Data: frame.Data, Err: err})
+			continue
+		}
+
+		if !sendEvent(ctx, events, val) {
+			return ctx.Err()
+		}
+	}
+}
+
+func sendEvent(ctx context.Context, events chan<- any, event any) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func reportError(errs chan<- error, err error) {
+	select {
+	case errs <- err:
+	default:
+	}
 }
@@ -23,6 +23,21 @@ const (
 	User      MessageInfoRole = "user"
 )
 
+// Defines values for LspDiagnosticSeverity.
+const (
+	LspDiagnosticSeverityError   LspDiagnosticSeverity = "error"
+	LspDiagnosticSeverityHint    LspDiagnosticSeverity = "hint"
+	LspDiagnosticSeverityInfo    LspDiagnosticSeverity = "info"
+	LspDiagnosticSeverityWarning LspDiagnosticSeverity = "warning"
+)
+
+// Defines values for PostPermissionRespondJSONBodyResponse.
+const (
+	Always PostPermissionRespondJSONBodyResponse = "always"
+	Once   PostPermissionRespondJSONBodyResponse = "once"
+	Reject PostPermissionRespondJSONBodyResponse = "reject"
+)
+
 // AppInfo defines model for App.Info.
 type AppInfo struct {
 	Git  bool `json:"git"`
@@ -51,8 +66,9 @@ type Event struct {
 // EventLspClientDiagnostics defines model for Event.lsp.client.diagnostics.
 type EventLspClientDiagnostics struct {
 	Properties struct {
-		Path     string `json:"path"`
-		ServerID string `json:"serverID"`
+		Diagnostics []LspDiagnostic `json:"diagnostics"`
+		Path        string          `json:"path"`
+		ServerID    string          `json:"serverID"`
 	} `json:"properties"`
 	Type string `json:"type"`
 }
@@ -84,7 +100,8 @@ type EventPermissionUpdated struct {
 // EventSessionError defines model for Event.session.error.
 type EventSessionError struct {
 	Properties struct {
-		Error *EventSessionError_Properties_Error `json:"error,omitempty"`
+		Error     *EventSessionError_Properties_Error `json:"error,omitempty"`
+		SessionID string                              `json:"sessionID"`
 	} `json:"properties"`
 	Type string `json:"type"`
 }
@@ -284,6 +301,18 @@ type UnknownError struct {
 	Name string `json:"name"`
 }
 
+// LspDiagnostic defines model for lsp.diagnostic.
+type LspDiagnostic struct {
+	Character float32               `json:"character"`
+	Line      float32               `json:"line"`
+	Message   string                `json:"message"`
+	Severity  LspDiagnosticSeverity `json:"severity"`
+	Source    *string               `json:"source,omitempty"`
+}
+
+// LspDiagnosticSeverity defines model for LspDiagnostic.Severity.
+type LspDiagnosticSeverity string
+
 // PermissionInfo defines model for permission.info.
 type PermissionInfo struct {
 	Id        string                 `json:"id"`
@@ -293,6 +322,7 @@ type PermissionInfo struct {
 		Created float32 `json:"created"`
 	} `json:"time"`
 	Title string `json:"title"`
+	Tool  string `json:"tool"`
 }
 
 // SessionInfo defines model for session.info.
@@ -310,11 +340,28 @@ type SessionInfo struct {
 	Title string `json:"title"`
 }
 
+// ToolInfo defines model for tool.info.
+type ToolInfo struct {
+	Description string                 `json:"description"`
+	Id          string                 `json:"id"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
 // PostFileSearchJSONBody defines parameters for PostFileSearch.
 type PostFileSearchJSONBody struct {
 	Query string `json:"query"`
 }
 
+// PostPermissionRespondJSONBody defines parameters for PostPermissionRespond.
+type PostPermissionRespondJSONBody struct {
+	PermissionID string                                `json:"permissionID"`
+	Response     PostPermissionRespondJSONBodyResponse `json:"response"`
+	SessionID    string                                `json:"sessionID"`
+}
+
+// PostPermissionRespondJSONBodyResponse defines parameters for PostPermissionRespond.
+type PostPermissionRespondJSONBodyResponse string
+
 // PostSessionAbortJSONBody defines parameters for PostSessionAbort.
 type PostSessionAbortJSONBody struct {
 	SessionID string `json:"sessionID"`
@@ -352,9 +399,17 @@ type PostSessionSummarizeJSONBody struct {
 	SessionID  string `json:"sessionID"`
 }
 
+// PostSessionUnshareJSONBody defines parameters for PostSessionUnshare.
+type PostSessionUnshareJSONBody struct {
+	SessionID string `json:"sessionID"`
+}
+
 // PostFileSearchJSONRequestBody defines body for PostFileSearch for application/json ContentType.
 type PostFileSearchJSONRequestBody PostFileSearchJSONBody
 
+// PostPermissionRespondJSONRequestBody defines body for PostPermissionRespond for application/json ContentType.
+type PostPermissionRespondJSONRequestBody PostPermissionRespondJSONBody
+
 // PostSessionAbortJSONRequestBody defines body for PostSessionAbort for application/json ContentType.
 type PostSessionAbortJSONRequestBody PostSessionAbortJSONBody
 
@@ -373,6 +428,9 @@ type PostSessionShareJSONRequestBody PostSessionShareJSONBody
 // PostSessionSummarizeJSONRequestBody defines body for PostSessionSummarize for application/json ContentType.
 type PostSessionSummarizeJSONRequestBody PostSessionSummarizeJSONBody
 
+// PostSessionUnshareJSONRequestBody defines body for PostSessionUnshare for application/json ContentType.
+type PostSessionUnshareJSONRequestBody PostSessionUnshareJSONBody
+
 // Getter for additional properties for MessageInfo_Metadata_Tool_AdditionalProperties. Returns the specified
 // element and whether it was found
 func (a MessageInfo_Metadata_Tool_AdditionalProperties) Get(fieldName string) (value interface{}, found bool) {
@@ -1287,6 +1345,11 @@ type ClientInterface interface {
 	// PostPathGet request
 	PostPathGet(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// PostPermissionRespondWithBody request with any body
+	PostPermissionRespondWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostPermissionRespond(ctx context.Context, body PostPermissionRespondJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// PostProviderList request
 	PostProviderList(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 
@@ -1325,6 +1388,14 @@ type ClientInterface interface {
 	PostSessionSummarizeWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
 
 	PostSessionSummarize(ctx context.Context, body PostSessionSummarizeJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostSessionUnshareWithBody request with any body
+	PostSessionUnshareWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	PostSessionUnshare(ctx context.Context, body PostSessionUnshareJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// PostToolList request
+	PostToolList(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
 
 func (c *Client) PostAppInfo(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
@@ -1399,6 +1470,30 @@ func (c *Client) PostPathGet(ctx context.Context, reqEditors ...RequestEditorFn)
 	return c.Client.Do(req)
 }
 
+func (c *Client) PostPermissionRespondWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostPermissionRespondRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostPermissionRespond(ctx context.Context, body PostPermissionRespondJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostPermissionRespondRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) PostProviderList(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewPostProviderListRequest(c.Server)
 	if err != nil {
@@ -1579,6 +1674,42 @@ func (c *Client) PostSessionSummarize(ctx context.Context, body PostSessionSumma
 	return c.Client.Do(req)
 }
 
+func (c *Client) PostSessionUnshareWithBody(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostSessionUnshareRequestWithBody(c.Server, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostSessionUnshare(ctx context.Context, body PostSessionUnshareJSONRequestBody, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostSessionUnshareRequest(c.Server, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) PostToolList(ctx context.Context, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewPostToolListRequest(c.Server)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 // NewPostAppInfoRequest generates requests for PostAppInfo
 func NewPostAppInfoRequest(server string) (*http.Request, error) {
 	var err error
@@ -1727,6 +1858,46 @@ func NewPostPathGetRequest(server string) (*http.Request, error) {
 	return req, nil
 }
 
+// NewPostPermissionRespondRequest calls the generic PostPermissionRespond builder with application/json body
+func NewPostPermissionRespondRequest(server string, body PostPermissionRespondJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostPermissionRespondRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPostPermissionRespondRequestWithBody generates requests for PostPermissionRespond with any type of body
+func NewPostPermissionRespondRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/permission_respond")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 // NewPostProviderListRequest generates requests for PostProviderList
 func NewPostProviderListRequest(server string) (*http.Request, error) {
 	var err error
@@ -2048,6 +2219,73 @@ func NewPostSessionSummarizeRequestWithBody(server string, contentType string, b
 	return req, nil
 }
 
+// NewPostSessionUnshareRequest calls the generic PostSessionUnshare builder with application/json body
+func NewPostSessionUnshareRequest(server string, body PostSessionUnshareJSONRequestBody) (*http.Request, error) {
+	var bodyReader io.Reader
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	bodyReader = bytes.NewReader(buf)
+	return NewPostSessionUnshareRequestWithBody(server, "application/json", bodyReader)
+}
+
+// NewPostSessionUnshareRequestWithBody generates requests for PostSessionUnshare with any type of body
+func NewPostSessionUnshareRequestWithBody(server string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/session_unshare")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
+// NewPostToolListRequest generates requests for PostToolList
+func NewPostToolListRequest(server string) (*http.Request, error) {
+	var err error
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/tool_list")
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
 func (c *Client) applyEditors(ctx context.Context, req *http.Request, additionalEditors []RequestEditorFn) error {
 	for _, r := range c.RequestEditors {
 		if err := r(ctx, req); err != nil {
@@ -2108,6 +2346,11 @@ type ClientWithResponsesInterface interface {
 	// PostPathGetWithResponse request
 	PostPathGetWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostPathGetResponse, error)
 
+	// PostPermissionRespondWithBodyWithResponse request with any body
+	PostPermissionRespondWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostPermissionRespondResponse, error)
+
+	PostPermissionRespondWithResponse(ctx context.Context, body PostPermissionRespondJSONRequestBody, reqEditors ...RequestEditorFn) (*PostPermissionRespondResponse, error)
+
 	// PostProviderListWithResponse request
 	PostProviderListWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostProviderListResponse, error)
 
@@ -2146,6 +2389,14 @@ type ClientWithResponsesInterface interface {
 	PostSessionSummarizeWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostSessionSummarizeResponse, error)
 
 	PostSessionSummarizeWithResponse(ctx context.Context, body PostSessionSummarizeJSONRequestBody, reqEditors ...RequestEditorFn) (*PostSessionSummarizeResponse, error)
+
+	// PostSessionUnshareWithBodyWithResponse request with any body
+	PostSessionUnshareWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostSessionUnshareResponse, error)
+
+	PostSessionUnshareWithResponse(ctx context.Context, body PostSessionUnshareJSONRequestBody, reqEditors ...RequestEditorFn) (*PostSessionUnshareResponse, error)
+
+	// PostToolListWithResponse request
+	PostToolListWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostToolListResponse, error)
 }
 
 type PostAppInfoResponse struct {
@@ -2263,6 +2514,28 @@ func (r PostPathGetResponse) StatusCode() int {
 	return 0
 }
 
+type PostPermissionRespondResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *bool
+}
+
+// Status returns HTTPResponse.Status
+func (r PostPermissionRespondResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostPermissionRespondResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type PostProviderListResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -2465,6 +2738,50 @@ func (r PostSessionSummarizeResponse) StatusCode() int {
 	return 0
 }
 
+type PostSessionUnshareResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *SessionInfo
+}
+
+// Status returns HTTPResponse.Status
+func (r PostSessionUnshareResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostSessionUnshareResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type PostToolListResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]ToolInfo
+}
+
+// Status returns HTTPResponse.Status
+func (r PostToolListResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r PostToolListResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 // PostAppInfoWithResponse request returning *PostAppInfoResponse
 func (c *ClientWithResponses) PostAppInfoWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostAppInfoResponse, error) {
 	rsp, err := c.PostAppInfo(ctx, reqEditors...)
@@ -2518,6 +2835,23 @@ func (c *ClientWithResponses) PostPathGetWithResponse(ctx context.Context, reqEd
 	return ParsePostPathGetResponse(rsp)
 }
 
+// PostPermissionRespondWithBodyWithResponse request with arbitrary body returning *PostPermissionRespondResponse
+func (c *ClientWithResponses) PostPermissionRespondWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostPermissionRespondResponse, error) {
+	rsp, err := c.PostPermissionRespondWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostPermissionRespondResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostPermissionRespondWithResponse(ctx context.Context, body PostPermissionRespondJSONRequestBody, reqEditors ...RequestEditorFn) (*PostPermissionRespondResponse, error) {
+	rsp, err := c.PostPermissionRespond(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostPermissionRespondResponse(rsp)
+}
+
 // PostProviderListWithResponse request returning *PostProviderListResponse
 func (c *ClientWithResponses) PostProviderListWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostProviderListResponse, error) {
 	rsp, err := c.PostProviderList(ctx, reqEditors...)
@@ -2647,6 +2981,32 @@ func (c *ClientWithResponses) PostSessionSummarizeWithResponse(ctx context.Conte
 	return ParsePostSessionSummarizeResponse(rsp)
 }
 
+// PostSessionUnshareWithBodyWithResponse request with arbitrary body returning *PostSessionUnshareResponse
+func (c *ClientWithResponses) PostSessionUnshareWithBodyWithResponse(ctx context.Context, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*PostSessionUnshareResponse, error) {
+	rsp, err := c.PostSessionUnshareWithBody(ctx, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostSessionUnshareResponse(rsp)
+}
+
+func (c *ClientWithResponses) PostSessionUnshareWithResponse(ctx context.Context, body PostSessionUnshareJSONRequestBody, reqEditors ...RequestEditorFn) (*PostSessionUnshareResponse, error) {
+	rsp, err := c.PostSessionUnshare(ctx, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostSessionUnshareResponse(rsp)
+}
+
+// PostToolListWithResponse request returning *PostToolListResponse
+func (c *ClientWithResponses) PostToolListWithResponse(ctx context.Context, reqEditors ...RequestEditorFn) (*PostToolListResponse, error) {
+	rsp, err := c.PostToolList(ctx, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePostToolListResponse(rsp)
+}
+
 // ParsePostAppInfoResponse parses an HTTP response from a PostAppInfoWithResponse call
 func ParsePostAppInfoResponse(rsp *http.Response) (*PostAppInfoResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -2782,6 +3142,32 @@ func ParsePostPathGetResponse(rsp *http.Response) (*PostPathGetResponse, error)
 	return response, nil
 }
 
+// ParsePostPermissionRespondResponse parses an HTTP response from a PostPermissionRespondWithResponse call
+func ParsePostPermissionRespondResponse(rsp *http.Response) (*PostPermissionRespondResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostPermissionRespondResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest bool
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
 // ParsePostProviderListResponse parses an HTTP response from a PostProviderListWithResponse call
 func ParsePostProviderListResponse(rsp *http.Response) (*PostProviderListResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
@@ -3025,3 +3411,55 @@ func ParsePostSessionSummarizeResponse(rsp *http.Response) (*PostSessionSummariz
 
 	return response, nil
 }
+
+// ParsePostSessionUnshareResponse parses an HTTP response from a PostSessionUnshareWithResponse call
+func ParsePostSessionUnshareResponse(rsp *http.Response) (*PostSessionUnshareResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostSessionUnshareResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest SessionInfo
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParsePostToolListResponse parses an HTTP response from a PostToolListWithResponse call
+func ParsePostToolListResponse(rsp *http.Response) (*PostToolListResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &PostToolListResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []ToolInfo
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
+	}
+
+	return response, nil
+}
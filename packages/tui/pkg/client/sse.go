@@ -0,0 +1,115 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseEvent is a single event dispatched from a text/event-stream body.
+type sseEvent struct {
+	// ID is the last event ID seen on the stream, which per the spec
+	// persists across events until the server sets a new one.
+	ID    string
+	Event string
+	Data  string
+}
+
+// sseReader parses a text/event-stream body as described in
+// https://html.spec.whatwg.org/multipage/server-sent-events.html
+type sseReader struct {
+	scanner     *bufio.Scanner
+	lastEventID string
+	// retry is the reconnection time requested by the server, if any.
+	retry time.Duration
+}
+
+// newSSEReader reads a stream, starting from the last event ID of the
+// previous connection: it carries over reconnects until the server sends
+// another one.
+func newSSEReader(r io.Reader, lastEventID string) *sseReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 10*1024*1024)
+	scanner.Split(scanSSELines)
+	return &sseReader{scanner: scanner, lastEventID: lastEventID}
+}
+
+// Next returns the next event on the stream. It returns io.EOF once the
+// stream ends; a partially received event is discarded.
+func (r *sseReader) Next() (sseEvent, error) {
+	event := sseEvent{}
+	data := strings.Builder{}
+	hasData := false
+
+	for r.scanner.Scan() {
+		line := r.scanner.Text()
+
+		// an empty line dispatches the event
+		if line == "" {
+			if !hasData {
+				event = sseEvent{}
+				continue
+			}
+			event.ID = r.lastEventID
+			event.Data = strings.TrimSuffix(data.String(), "\n")
+			return event, nil
+		}
+
+		// lines starting with a colon are comments
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+			hasData = true
+		case "id":
+			if !strings.Contains(value, "\x00") {
+				r.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				r.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return sseEvent{}, err
+	}
+	return sseEvent{}, io.EOF
+}
+
+// scanSSELines splits on CRLF, LF or a lone CR, as required by the spec.
+func scanSSELines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// a CR at the end of the buffer may be followed by an LF
+		if i+1 == len(data) && !atEOF {
+			return 0, nil, nil
+		}
+		if i+1 < len(data) && data[i+1] == '\n' {
+			return i + 2, data[:i], nil
+		}
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		stream      string
+		lastEventID string
+		want        []sseEvent
+	}{
+		{
+			name:   "single event",
+			stream: "data: {}\n\n",
+			want:   []sseEvent{{Data: "{}"}},
+		},
+		{
+			name:   "multi-line data",
+			stream: "data: first\ndata: second\ndata:third\n\n",
+			want:   []sseEvent{{Data: "first\nsecond\nthird"}},
+		},
+		{
+			name:   "CRLF stream",
+			stream: "event: update\r\ndata: one\r\n\r\ndata: two\r\n\r\n",
+			want:   []sseEvent{{Event: "update", Data: "one"}, {Data: "two"}},
+		},
+		{
+			name:   "lone CR",
+			stream: "data: one\rdata: two\r\r",
+			want:   []sseEvent{{Data: "one\ntwo"}},
+		},
+		{
+			name:   "comment line",
+			stream: ": keep-alive\ndata: one\n:another\n\n",
+			want:   []sseEvent{{Data: "one"}},
+		},
+		{
+			name:   "comment only",
+			stream: ": keep-alive\n\ndata: one\n\n",
+			want:   []sseEvent{{Data: "one"}},
+		},
+		{
+			name:   "event without data is dropped",
+			stream: "event: ping\n\ndata: one\n\n",
+			want:   []sseEvent{{Data: "one"}},
+		},
+		{
+			name:   "id persists across events",
+			stream: "id: 1\ndata: one\n\ndata: two\n\nid: 3\ndata: three\n\n",
+			want:   []sseEvent{{ID: "1", Data: "one"}, {ID: "1", Data: "two"}, {ID: "3", Data: "three"}},
+		},
+		{
+			name:        "id carries over from the previous connection",
+			stream:      "data: one\n\n",
+			lastEventID: "7",
+			want:        []sseEvent{{ID: "7", Data: "one"}},
+		},
+		{
+			name:        "empty id resets it",
+			stream:      "id\ndata: one\n\n",
+			lastEventID: "7",
+			want:        []sseEvent{{Data: "one"}},
+		},
+		{
+			name:        "id with NUL is ignored",
+			stream:      "id: a\x00b\ndata: one\n\n",
+			lastEventID: "7",
+			want:        []sseEvent{{ID: "7", Data: "one"}},
+		},
+		{
+			name:   "unknown field is ignored",
+			stream: "foo: bar\ndata: one\n\n",
+			want:   []sseEvent{{Data: "one"}},
+		},
+		{
+			name:   "partial event is discarded",
+			stream: "data: one\n\ndata: two\n",
+			want:   []sseEvent{{Data: "one"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newSSEReader(strings.NewReader(tt.stream), tt.lastEventID)
+			var got []sseEvent
+			for {
+				event, err := reader.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next() error = %v", err)
+				}
+				got = append(got, event)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEReaderRetry(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   time.Duration
+	}{
+		{name: "milliseconds", stream: "retry: 1500\n\n", want: 1500 * time.Millisecond},
+		{name: "not a number", stream: "retry: soon\n\n", want: 0},
+		{name: "negative", stream: "retry: -1\n\n", want: 0},
+		{name: "last one wins", stream: "retry: 10\nretry: 20\n\n", want: 20 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newSSEReader(strings.NewReader(tt.stream), "")
+			if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+				t.Fatalf("Next() error = %v, want io.EOF", err)
+			}
+			if reader.retry != tt.want {
+				t.Errorf("retry = %v, want %v", reader.retry, tt.want)
+			}
+		})
+	}
+}
+
+// chunkReader returns the stream a few bytes at a time, so a CRLF can be
+// split across reads.
+type chunkReader struct {
+	data []byte
+	size int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := min(r.size, len(p), len(r.data))
+	copy(p, r.data[:n])
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestSSEReaderSplitCRLF(t *testing.T) {
+	for size := 1; size <= 4; size++ {
+		reader := newSSEReader(&chunkReader{data: []byte("data: one\r\ndata: two\r\n\r\n"), size: size}, "")
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatalf("size %d: Next() error = %v", size, err)
+		}
+		if event.Data != "one\ntwo" {
+			t.Errorf("size %d: data = %q, want %q", size, event.Data, "one\ntwo")
+		}
+		if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+			t.Errorf("size %d: second Next() error = %v, want io.EOF", size, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+)
+
+// unixServer is the base URL used for requests sent over a Unix socket. The
+// host is never resolved; every connection is dialed to the socket instead.
+const unixServer = "http://unix/"
+
+// TransportConfig describes how to reach the opencode server.
+type TransportConfig struct {
+	// Server is an http://, https:// or unix:// URL, for example
+	// unix:///tmp/opencode.sock.
+	Server string
+	// Token is sent as a bearer token with every request when set.
+	Token string
+	// CAFile is a PEM bundle used to verify the server's certificate.
+	CAFile string
+	// CertFile and KeyFile are a client certificate for mutual TLS.
+	CertFile string
+	KeyFile  string
+	// InsecureSkipVerify disables verification of the server's certificate.
+	InsecureSkipVerify bool
+}
+
+// TransportConfigFromEnv reads the transport settings from OPENCODE_SERVER,
+// OPENCODE_SERVER_TOKEN, OPENCODE_SERVER_CA, OPENCODE_SERVER_CERT,
+// OPENCODE_SERVER_KEY and OPENCODE_SERVER_INSECURE.
+func TransportConfigFromEnv() TransportConfig {
+	insecure, _ := strconv.ParseBool(os.Getenv("OPENCODE_SERVER_INSECURE"))
+	return TransportConfig{
+		Server:             os.Getenv("OPENCODE_SERVER"),
+		Token:              os.Getenv("OPENCODE_SERVER_TOKEN"),
+		CAFile:             os.Getenv("OPENCODE_SERVER_CA"),
+		CertFile:           os.Getenv("OPENCODE_SERVER_CERT"),
+		KeyFile:            os.Getenv("OPENCODE_SERVER_KEY"),
+		InsecureSkipVerify: insecure,
+	}
+}
+
+// ServerURL returns the base URL to pass to NewClient. Unix socket addresses
+// are replaced with a placeholder http URL.
+func (t TransportConfig) ServerURL() string {
+	if socket, ok := t.socketPath(); ok && socket != "" {
+		return unixServer
+	}
+	return t.Server
+}
+
+// Options returns the client options that apply the transport settings.
+// Pass them to NewClient or NewClientWithResponses together with ServerURL.
+func (t TransportConfig) Options() ([]ClientOption, error) {
+	opts := []ClientOption{}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	custom := false
+
+	if socket, ok := t.socketPath(); ok {
+		if socket == "" {
+			return nil, fmt.Errorf("invalid unix socket address %q", t.Server)
+		}
+		dialer := net.Dialer{}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		custom = true
+	}
+
+	if t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.InsecureSkipVerify {
+		tlsConfig, err := t.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+		custom = true
+	}
+
+	if custom {
+		opts = append(opts, WithHTTPClient(&http.Client{Transport: transport}))
+	}
+	if t.Token != "" {
+		opts = append(opts, WithBearerToken(t.Token))
+	}
+	return opts, nil
+}
+
+func (t TransportConfig) socketPath() (string, bool) {
+	u, err := url.Parse(t.Server)
+	if err != nil || u.Scheme != "unix" {
+		return "", false
+	}
+	if u.Path != "" {
+		return u.Path, true
+	}
+	// unix://relative.sock
+	return u.Host + u.Opaque, true
+}
+
+func (t TransportConfig) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		if t.CertFile == "" || t.KeyFile == "" {
+			return nil, fmt.Errorf("both a client certificate and key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// WithBearerToken sends token in the Authorization header of every request.
+func WithBearerToken(token string) ClientOption {
+	return WithRequestEditorFn(func(ctx context.Context, req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}